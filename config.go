@@ -0,0 +1,279 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"roudo/roudo"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+)
+
+// config.toml の例
+//
+//	shift_duration = "5h"
+//	start_break_interval = "35m"
+//	finish_working_interval = "4h"
+//	lock_finish_working_interval = "2h"
+//	polling_interval = "1s"
+//	event_batch_interval = "10s"
+//	on_exit = "keep" # keep, break, finish
+//	notificator = "auto" # auto, mac, freedesktop, none
+//	holidays_file = "~/.roudo/holidays.txt"
+//	storage = "buntdb" # buntdb, sqlite, json
+//
+//	[[activity_rules]]
+//	# window はウィンドウのタイトル、cwd は前面のプロセスのカレントディレクトリ、git は cwd を含む git リポジトリのルート
+//	target = "git" # window, cwd, git
+//	pattern = "/src/([^/]+)$"
+//	label = "$1"
+type config struct {
+	Dir         string
+	Notificator string
+	Storage     string
+	// 会社独自の休日を1行に「YYYY-MM-DD 名前」の形式で書いたファイル
+	HolidaysFile string
+	roudo.Config
+}
+
+type fileConfig struct {
+	ShiftDuration             *string `toml:"shift_duration"`
+	StartBreakInterval        *string `toml:"start_break_interval"`
+	FinishWorkingInterval     *string `toml:"finish_working_interval"`
+	LockFinishWorkingInterval *string `toml:"lock_finish_working_interval"`
+	PollingInterval           *string `toml:"polling_interval"`
+	EventBatchInterval        *string `toml:"event_batch_interval"`
+	OnExit                    *string `toml:"on_exit"`
+	Notificator               *string `toml:"notificator"`
+	HolidaysFile              *string `toml:"holidays_file"`
+	Storage                   *string `toml:"storage"`
+	ActivityRules             []struct {
+		Target  string `toml:"target"`
+		Pattern string `toml:"pattern"`
+		Label   string `toml:"label"`
+	} `toml:"activity_rules"`
+}
+
+var globalFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "dir",
+		Usage:   "roudo のデータディレクトリ (default: ~/.roudo)",
+		EnvVars: []string{"ROUDO_DIR"},
+	},
+	&cli.StringFlag{
+		Name:    "config",
+		Usage:   "設定ファイルのパス (default: <dir>/config.toml)",
+		EnvVars: []string{"ROUDO_CONFIG"},
+	},
+	&cli.DurationFlag{
+		Name:    "shift-duration",
+		Usage:   "日付の切り替わりを 0:00 からずらす時間",
+		EnvVars: []string{"ROUDO_SHIFT_DURATION"},
+	},
+	&cli.DurationFlag{
+		Name:    "start-break-interval",
+		Usage:   "最終イベントから休憩開始とみなすまでの時間",
+		EnvVars: []string{"ROUDO_START_BREAK_INTERVAL"},
+	},
+	&cli.DurationFlag{
+		Name:    "finish-working-interval",
+		Usage:   "最終イベントから労働終了とみなすまでの時間",
+		EnvVars: []string{"ROUDO_FINISH_WORKING_INTERVAL"},
+	},
+	&cli.DurationFlag{
+		Name:    "lock-finish-working-interval",
+		Usage:   "画面ロック・スリープから労働終了とみなすまでの時間",
+		EnvVars: []string{"ROUDO_LOCK_FINISH_WORKING_INTERVAL"},
+	},
+	&cli.DurationFlag{
+		Name:    "polling-interval",
+		Usage:   "監視のポーリング間隔",
+		EnvVars: []string{"ROUDO_POLLING_INTERVAL"},
+	},
+	&cli.DurationFlag{
+		Name:    "event-batch-interval",
+		Usage:   "労働中に入力イベントをまとめて保存する間隔 (0 で毎回保存)",
+		EnvVars: []string{"ROUDO_EVENT_BATCH_INTERVAL"},
+	},
+	&cli.StringFlag{
+		Name:    "on-exit",
+		Usage:   "kansi 終了時に進行中の労働をどうするか (keep, break, finish)",
+		EnvVars: []string{"ROUDO_ON_EXIT"},
+	},
+	&cli.StringFlag{
+		Name:    "notificator",
+		Usage:   "通知方法 (auto, mac, freedesktop, none)",
+		EnvVars: []string{"ROUDO_NOTIFICATOR"},
+	},
+	&cli.StringFlag{
+		Name:    "holidays-file",
+		Usage:   "会社休日のファイル (default: <dir>/holidays.txt)",
+		EnvVars: []string{"ROUDO_HOLIDAYS_FILE"},
+	},
+	&cli.StringFlag{
+		Name:    "storage",
+		Usage:   "保存先 (buntdb, sqlite, json)",
+		EnvVars: []string{"ROUDO_STORAGE"},
+	},
+}
+
+// loadConfig はデフォルト値、設定ファイル、環境変数・フラグの順に上書きした設定を返す
+func loadConfig(c *cli.Context) (*config, error) {
+	dir, err := getRoudoDir(c.String("dir"))
+	if err != nil {
+		return nil, err
+	}
+	cfg := &config{
+		Dir:         dir,
+		Notificator: "auto",
+		Storage:     storageBuntDB,
+		Config:      roudo.DefaultConfig(),
+	}
+
+	path := c.String("config")
+	if path == "" {
+		path = filepath.Join(dir, "config.toml")
+	}
+	if err := cfg.loadFile(path, c.IsSet("config")); err != nil {
+		return nil, err
+	}
+
+	overrides := []struct {
+		flag string
+		dst  *time.Duration
+	}{
+		{"shift-duration", &cfg.ShiftDuration},
+		{"start-break-interval", &cfg.StartBreakInterval},
+		{"finish-working-interval", &cfg.FinishWorkingInterval},
+		{"lock-finish-working-interval", &cfg.LockFinishWorkingInterval},
+		{"polling-interval", &cfg.PollingInterval},
+		{"event-batch-interval", &cfg.EventBatchInterval},
+	}
+	for _, o := range overrides {
+		if c.IsSet(o.flag) {
+			*o.dst = c.Duration(o.flag)
+		}
+	}
+	if c.IsSet("on-exit") {
+		cfg.OnExit = roudo.ExitAction(c.String("on-exit"))
+	}
+	if c.IsSet("notificator") {
+		cfg.Notificator = c.String("notificator")
+	}
+	if c.IsSet("holidays-file") {
+		cfg.HolidaysFile = c.String("holidays-file")
+	}
+	if c.IsSet("storage") {
+		cfg.Storage = c.String("storage")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("設定が不正です: %w", err)
+	}
+	if _, err := roudo.NewNotificator(cfg.Notificator); err != nil {
+		return nil, fmt.Errorf("設定が不正です: %w", err)
+	}
+	if !slices.Contains(storages, cfg.Storage) {
+		return nil, fmt.Errorf("設定が不正です: 未対応の storage です: %s", cfg.Storage)
+	}
+	return cfg, nil
+}
+
+func (cfg *config) loadFile(path string, required bool) error {
+	var fc fileConfig
+	md, err := toml.DecodeFile(path, &fc)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !required {
+			return nil
+		}
+		return fmt.Errorf("設定ファイルの読み込みに失敗しました: %s: %w", path, err)
+	}
+	// 書き間違えた設定が黙って無視されないようにする
+	if undecoded := md.Undecoded(); len(undecoded) != 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, k := range undecoded {
+			keys = append(keys, k.String())
+		}
+		return fmt.Errorf("%s: 不明な設定があります: %s", path, strings.Join(keys, ", "))
+	}
+
+	durations := []struct {
+		key string
+		src *string
+		dst *time.Duration
+	}{
+		{"shift_duration", fc.ShiftDuration, &cfg.ShiftDuration},
+		{"start_break_interval", fc.StartBreakInterval, &cfg.StartBreakInterval},
+		{"finish_working_interval", fc.FinishWorkingInterval, &cfg.FinishWorkingInterval},
+		{"lock_finish_working_interval", fc.LockFinishWorkingInterval, &cfg.LockFinishWorkingInterval},
+		{"polling_interval", fc.PollingInterval, &cfg.PollingInterval},
+		{"event_batch_interval", fc.EventBatchInterval, &cfg.EventBatchInterval},
+	}
+	for _, d := range durations {
+		if d.src == nil {
+			continue
+		}
+		v, err := time.ParseDuration(*d.src)
+		if err != nil {
+			return fmt.Errorf("%s: %s の形式が不正です: %w", path, d.key, err)
+		}
+		*d.dst = v
+	}
+	if fc.OnExit != nil {
+		cfg.OnExit = roudo.ExitAction(*fc.OnExit)
+	}
+	if fc.Notificator != nil {
+		cfg.Notificator = *fc.Notificator
+	}
+	if fc.HolidaysFile != nil {
+		cfg.HolidaysFile = *fc.HolidaysFile
+	}
+	if fc.Storage != nil {
+		cfg.Storage = *fc.Storage
+	}
+	for i, r := range fc.ActivityRules {
+		rule, err := roudo.NewActivityRule(r.Target, r.Pattern, r.Label)
+		if err != nil {
+			return fmt.Errorf("%s: activity_rules[%d] が不正です: %w", path, i, err)
+		}
+		cfg.ActivityRules = append(cfg.ActivityRules, rule)
+	}
+	return nil
+}
+
+// holidayCalendar は祝日と会社休日のカレンダーを返す。holidays_file 未指定時は <dir>/holidays.txt があれば読み込む
+func (cfg *config) holidayCalendar() (*roudo.HolidayCalendar, error) {
+	path, err := expandHome(cfg.HolidaysFile)
+	if err != nil {
+		return nil, err
+	}
+	required := path != ""
+	if !required {
+		path = filepath.Join(cfg.Dir, "holidays.txt")
+	}
+
+	company, err := roudo.LoadCompanyHolidays(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return roudo.NewHolidayCalendar(nil), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("会社休日の読み込みに失敗しました: %w", err)
+	}
+	return roudo.NewHolidayCalendar(company), nil
+}
+
+// expandHome は設定ファイルに書かれた先頭の ~ をホームディレクトリに置き換える
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"roudo/roudo"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigLoadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		toml    string
+		wantErr string
+	}{
+		{
+			name: "既知の設定",
+			toml: `shift_duration = "4h"
+[[activity_rules]]
+target = "git"
+pattern = "/src/([^/]+)$"
+label = "$1"
+`,
+		},
+		{
+			name:    "書き間違えた設定",
+			toml:    "shift_duraton = \"4h\"\npolling_interval = \"2s\"\nnotifcator = \"none\"\n",
+			wantErr: "不明な設定があります: shift_duraton, notifcator",
+		},
+		{
+			name:    "テーブルの中の書き間違えた設定",
+			toml:    "[[activity_rules]]\ntarget = \"git\"\npatern = \"x\"\nlabel = \"y\"\n",
+			wantErr: "activity_rules.patern",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.toml), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg := &config{Config: roudo.DefaultConfig()}
+			err := cfg.loadFile(path, true)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cfg.ShiftDuration != 4*time.Hour {
+					t.Errorf("shift_duration = %s", cfg.ShiftDuration)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"roudo/roudo_daemon"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	daemonStartTimeout = 5 * time.Second
+	daemonStopTimeout  = 10 * time.Second
+)
+
+var daemonCommand = &cli.Command{
+	Name:  "daemon",
+	Usage: "kansi をバックグラウンドで動かす",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "start",
+			Usage:  "デーモンを起動",
+			Action: daemonStart,
+		},
+		{
+			Name:   "stop",
+			Usage:  "デーモンを停止",
+			Action: daemonStop,
+		},
+		{
+			Name:  "restart",
+			Usage: "デーモンを再起動",
+			Action: func(c *cli.Context) error {
+				if err := daemonStop(c); err != nil && !errors.Is(err, roudo_daemon.ErrNotRunning) {
+					return err
+				}
+				return daemonStart(c)
+			},
+		},
+		{
+			Name:   "status",
+			Usage:  "デーモンの状態を表示",
+			Action: daemonStatus,
+		},
+		{
+			// daemon start から起動される本体
+			Name:   "run",
+			Hidden: true,
+			Action: daemonRun,
+		},
+	},
+}
+
+func daemonStart(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	if pid, err := roudo_daemon.ReadPID(cfg.Dir); err == nil {
+		return fmt.Errorf("デーモンは既に起動しています (pid %d)", pid)
+	} else if !errors.Is(err, roudo_daemon.ErrNotRunning) {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(filepath.Join(cfg.Dir, roudo_daemon.LogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, "daemon", "run")
+	// グローバルフラグは環境変数で引き継ぐ
+	cmd.Env = append(os.Environ(), "ROUDO_DIR="+cfg.Dir)
+	cmd.Env = append(cmd.Env, globalFlagEnv(c)...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	roudo_daemon.Detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("デーモンを起動できませんでした: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	deadline := time.After(daemonStartTimeout)
+	for {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("デーモンが起動直後に終了しました。%s を確認してください: %v", filepath.Join(cfg.Dir, roudo_daemon.LogFile), err)
+		case <-deadline:
+			return fmt.Errorf("デーモンが %s 以内に起動しませんでした", daemonStartTimeout)
+		case <-time.After(100 * time.Millisecond):
+		}
+		client, err := roudo_daemon.Dial(cfg.Dir)
+		if err != nil {
+			continue
+		}
+		client.Close()
+		fmt.Fprintf(c.App.Writer, "デーモンを起動しました (pid %d)\n", cmd.Process.Pid)
+		return nil
+	}
+}
+
+func daemonStop(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	pid, err := roudo_daemon.Terminate(cfg.Dir, daemonStopTimeout)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "デーモンを停止しました (pid %d)\n", pid)
+	return nil
+}
+
+func daemonStatus(c *cli.Context) error {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+	client, err := roudo_daemon.Dial(cfg.Dir)
+	if errors.Is(err, roudo_daemon.ErrNotRunning) {
+		fmt.Fprintln(c.App.Writer, "停止中")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ping, err := client.Ping()
+	if err != nil {
+		return err
+	}
+	st, err := client.GetStatus(time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.App.Writer, "起動中 (pid %d, %s から)\n", ping.PID, ping.StartedAt.Local().Format("2006-01-02 15:04"))
+	return writeStatusText(c.App.Writer, st)
+}
+
+func daemonRun(c *cli.Context) error {
+	env, err := newRoudoEnv(c)
+	if err != nil {
+		return err
+	}
+	defer env.Close()
+
+	server, err := roudo_daemon.Listen(env.cfg.Dir, env.reporter, env.logger)
+	if err != nil {
+		return err
+	}
+	defer server.Close()
+
+	return kansi(c, env)
+}
+
+// globalFlagEnv は指定されたグローバルフラグを、対応する環境変数の形式で返す
+func globalFlagEnv(c *cli.Context) []string {
+	var env []string
+	for _, f := range globalFlags {
+		name := f.Names()[0]
+		df, ok := f.(cli.DocGenerationFlag)
+		if !ok || name == "dir" || !c.IsSet(name) || len(df.GetEnvVars()) == 0 {
+			continue
+		}
+		env = append(env, fmt.Sprintf("%s=%v", df.GetEnvVars()[0], c.Value(name)))
+	}
+	return env
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"roudo/roudo"
+
+	"github.com/urfave/cli/v2"
+)
+
+var doctorCommand = &cli.Command{
+	Name:  "doctor",
+	Usage: "保存されている労働記録の不整合を調べる",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "fix",
+			Usage: "安全に直せる不整合を直す",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		editor, closeEditor, err := openEditor(c)
+		if err != nil {
+			return err
+		}
+		defer closeEditor()
+
+		findings, err := editor.Doctor(c.Bool("fix"))
+		if err != nil {
+			return err
+		}
+		return writeFindings(c.App.Writer, findings, c.Bool("fix"))
+	},
+}
+
+func writeFindings(w io.Writer, findings []roudo.Finding, fix bool) error {
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "問題は見つかりませんでした")
+		return nil
+	}
+
+	remaining, fixable := 0, 0
+	for _, f := range findings {
+		date := string(f.Date)
+		if date == "" {
+			date = "current_state"
+		}
+		note := ""
+		switch {
+		case f.Fixed:
+			note = " (修復しました)"
+		case f.Fixable:
+			note = " (--fix で修復できます)"
+			fixable++
+		}
+		if !f.Fixed && f.Severity == roudo.SeverityError {
+			remaining++
+		}
+		fmt.Fprintf(w, "[%s] %s: %s%s\n", f.Severity, date, f.Message, note)
+	}
+
+	if !fix && fixable != 0 {
+		fmt.Fprintf(w, "%d 件の問題のうち %d 件は --fix で修復できます\n", len(findings), fixable)
+	}
+	if remaining != 0 {
+		return fmt.Errorf("修復されていない error が %d 件あります", remaining)
+	}
+	return nil
+}
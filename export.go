@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"roudo/view"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+var exportCommand = &cli.Command{
+	Name:  "export",
+	Usage: "月次の勤怠を出力",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "month",
+			Usage: "出力する月 ex: 2024-03 (default: 今月)",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "出力形式 (csv, json, md, html, table)",
+			Value: string(view.ExportFormatCSV),
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "出力先のファイル (default: 標準出力)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		month := c.String("month")
+		if month == "" {
+			month = time.Now().Format("2006-01")
+		}
+
+		cfg, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+		editor, closeEditor, err := openEditor(c)
+		if err != nil {
+			return err
+		}
+		defer closeEditor()
+
+		w := c.App.Writer
+		if path := c.String("output"); path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+
+		calendar, err := cfg.holidayCalendar()
+		if err != nil {
+			return err
+		}
+
+		viewRepo := view.NewViewRepository(editor)
+		exporter, err := view.NewExporter(viewRepo, calendar, view.ExportFormat(c.String("format")), w)
+		if err != nil {
+			return err
+		}
+		p, err := view.MonthPeriod(month)
+		if err != nil {
+			return err
+		}
+		return exporter.Do(p)
+	},
+}
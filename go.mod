@@ -3,20 +3,30 @@ module roudo
 go 1.21.3
 
 require (
+	github.com/BurntSushi/toml v1.4.0
+	github.com/alexflint/go-filemutex v1.3.0
+	github.com/gdamore/tcell/v2 v2.7.1
+	github.com/godbus/dbus/v5 v5.1.0
 	github.com/jedib0t/go-pretty/v6 v6.5.9
 	github.com/rivo/tview v0.0.0-20240524063012-037df494fb76
 	github.com/robotn/gohook v0.41.0
 	github.com/tidwall/buntdb v1.3.0
 	github.com/urfave/cli/v2 v2.27.2
+	golang.org/x/sys v0.19.0
+	modernc.org/sqlite v1.29.10
 )
 
 require (
-	github.com/alexflint/go-filemutex v1.3.0 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.4 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/gdamore/encoding v1.0.0 // indirect
-	github.com/gdamore/tcell/v2 v2.7.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mattn/go-runewidth v0.0.15 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/tidwall/btree v1.7.0 // indirect
@@ -28,9 +38,14 @@ require (
 	github.com/tidwall/tinyqueue v0.1.1 // indirect
 	github.com/vcaesar/keycode v0.10.1 // indirect
 	github.com/xrash/smetrics v0.0.0-20240312152122-5f08fbb34913 // indirect
-	golang.org/x/sys v0.17.0 // indirect
 	golang.org/x/term v0.17.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
+	modernc.org/gc/v3 v3.0.0-20240107210532-573471604cb6 // indirect
+	modernc.org/libc v1.49.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
+	modernc.org/strutil v1.2.0 // indirect
+	modernc.org/token v1.1.0 // indirect
 )
 
 replace github.com/robotn/gohook => github.com/tockn/gohook v1.0.0
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"roudo/roudo"
+	"slices"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+var sourceLabels = map[roudo.AuditSource]string{
+	roudo.AuditSourceAuto:    "自動判定",
+	roudo.AuditSourceTUI:     "view での編集",
+	roudo.AuditSourceImport:  "import",
+	roudo.AuditSourceCLI:     "コマンド",
+	roudo.AuditSourceDoctor:  "doctor",
+	roudo.AuditSourceMigrate: "migrate",
+}
+
+var historyCommand = &cli.Command{
+	Name:      "history",
+	Usage:     "労働記録の変更履歴を表示",
+	ArgsUsage: "[YYYY-MM-DD]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "出力形式 (text, json)",
+			Value: "text",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		format := c.String("format")
+		if format != "text" && format != "json" {
+			return fmt.Errorf("未対応の出力形式です: %s", format)
+		}
+
+		cfg, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+		date := roudo.Date(c.Args().First())
+		if date == "" {
+			date = roudo.NewRoudoTime(time.Now(), cfg.ShiftDuration).ShiftedDate()
+		} else if _, err := date.Time(); err != nil {
+			return fmt.Errorf("日付の指定が不正です ex: 2024-03-01")
+		}
+
+		entries, err := roudo.NewFileAuditLog(auditLogPath(cfg.Dir)).List(date)
+		if err != nil {
+			return err
+		}
+		if format == "json" {
+			if entries == nil {
+				entries = []roudo.AuditEntry{}
+			}
+			return json.NewEncoder(c.App.Writer).Encode(entries)
+		}
+		return writeHistoryText(c.App.Writer, date, entries)
+	},
+}
+
+func writeHistoryText(w io.Writer, date roudo.Date, entries []roudo.AuditEntry) error {
+	if len(entries) == 0 {
+		fmt.Fprintf(w, "%s の変更履歴はありません\n", date)
+		return nil
+	}
+
+	fmt.Fprintf(w, "%s の変更履歴 (%d 件)\n", date, len(entries))
+	for i, e := range entries {
+		before, err := e.BeforeRoudos()
+		if err != nil {
+			return err
+		}
+		after, err := e.AfterRoudos()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "#%d %s %s\n", i+1, e.At.Local().Format("2006-01-02 15:04:05"), sourceLabels[e.Source])
+		writeRoudoDiff(w, before, after)
+	}
+	return nil
+}
+
+// writeRoudoDiff は書き換えで消えた労働を -、増えた労働を + で表示する。休憩だけが変わった場合も労働ごと入れ替えて表示する
+func writeRoudoDiff(w io.Writer, before, after []roudo.Roudo) {
+	bs := make([]string, 0, len(before))
+	for _, r := range before {
+		bs = append(bs, roudoSummary(r))
+	}
+	as := make([]string, 0, len(after))
+	for _, r := range after {
+		as = append(as, roudoSummary(r))
+	}
+
+	changed := false
+	for _, s := range bs {
+		if !slices.Contains(as, s) {
+			fmt.Fprintf(w, "    - %s\n", s)
+			changed = true
+		}
+	}
+	for _, s := range as {
+		if !slices.Contains(bs, s) {
+			fmt.Fprintf(w, "    + %s\n", s)
+			changed = true
+		}
+	}
+	if !changed {
+		// 分単位の時刻は同じで、秒やプロジェクト・作業内容だけが変わった
+		fmt.Fprintln(w, "    ~ 秒単位の時刻・プロジェクト・作業内容の変更")
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"roudo/roudo"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+var importCommand = &cli.Command{
+	Name:      "import",
+	Usage:     "export と同じ形式の勤怠データを取り込む",
+	ArgsUsage: "FILE",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "入力形式 (csv, json)。省略時は拡張子から判定",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "既存データとの差分を表示するだけで保存しない",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		path := c.Args().First()
+		if path == "" {
+			return fmt.Errorf("取り込むファイルを指定してください")
+		}
+		format := roudo.ImportFormat(c.String("format"))
+		if format == "" {
+			format = roudo.ImportFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+		}
+
+		cfg, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+		editor, closeEditor, err := openEditor(c)
+		if err != nil {
+			return err
+		}
+		defer closeEditor()
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		reports, err := roudo.NewImporter(cfg.ShiftDuration, time.Local).Parse(f, format)
+		if err != nil {
+			return err
+		}
+
+		dates := make([]roudo.Date, 0, len(reports))
+		for date := range reports {
+			dates = append(dates, date)
+		}
+		slices.Sort(dates)
+		var currents map[roudo.Date][]roudo.Roudo
+		if len(dates) != 0 {
+			currents, err = editor.ListRoudoReports(dates[0], dates[len(dates)-1])
+			if err != nil {
+				return err
+			}
+		}
+
+		validator := roudo.NewValidator(cfg.ShiftDuration)
+		var changed, invalid []roudo.Date
+		for _, date := range dates {
+			current := currents[date]
+			diff, err := printImportDiff(c.App.Writer, date, current, reports[date])
+			if err != nil {
+				return err
+			}
+			if !diff {
+				continue
+			}
+			changed = append(changed, date)
+			if printValidationIssues(c.App.Writer, validator.ValidateChange(date, current, reports[date])) {
+				invalid = append(invalid, date)
+			}
+		}
+		if len(invalid) != 0 {
+			return fmt.Errorf("%d 日分の記録に不整合があるため取り込めません", len(invalid))
+		}
+
+		if c.Bool("dry-run") {
+			fmt.Fprintf(c.App.Writer, "dry-run: %d 日分の変更があります\n", len(changed))
+			return nil
+		}
+		for _, date := range changed {
+			// 差分を表示した後に書き換えられていれば、確認していない記録を上書きしないよう止める
+			if err := editor.ReplaceRoudoReport(date, currents[date], reports[date], roudo.AuditSourceImport); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(c.App.Writer, "%d 日分を取り込みました\n", len(changed))
+		return nil
+	},
+}
+
+// printImportDiff は既存データと取り込むデータの差分を表示し、差分があるかを返す
+func printImportDiff(w io.Writer, date roudo.Date, current, imported []roudo.Roudo) (bool, error) {
+	cur, err := json.Marshal(current)
+	if err != nil {
+		return false, err
+	}
+	imp, err := json.Marshal(imported)
+	if err != nil {
+		return false, err
+	}
+	if string(cur) == string(imp) {
+		fmt.Fprintf(w, "  %s (変更なし)\n", date)
+		return false, nil
+	}
+
+	if len(current) == 0 {
+		fmt.Fprintf(w, "+ %s (新規)\n", date)
+	} else {
+		fmt.Fprintf(w, "~ %s (上書き)\n", date)
+	}
+	for _, r := range current {
+		fmt.Fprintf(w, "    - %s\n", roudoSummary(r))
+	}
+	for _, r := range imported {
+		fmt.Fprintf(w, "    + %s\n", roudoSummary(r))
+	}
+	return true, nil
+}
+
+// printValidationIssues は不整合を表示し、保存を拒否すべきものがあるかを返す
+func printValidationIssues(w io.Writer, issues roudo.ValidationIssues) bool {
+	invalid := false
+	for _, i := range issues {
+		if i.Warning {
+			fmt.Fprintf(w, "    警告: %s\n", i.Message)
+		} else {
+			fmt.Fprintf(w, "    エラー: %s\n", i.Message)
+			invalid = true
+		}
+	}
+	return invalid
+}
+
+func roudoSummary(r roudo.Roudo) string {
+	var sb strings.Builder
+	sb.WriteString(formatTimePtr(r.StartAt) + "~" + formatTimePtr(r.EndAt))
+	for _, b := range r.Breaks {
+		sb.WriteString(" 休憩 " + b.StartAt.Local().Format("15:04") + "~" + formatTimePtr(b.EndAt))
+	}
+	return sb.String()
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return "--:--"
+	}
+	return t.Local().Format("15:04")
+}
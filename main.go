@@ -1,24 +1,28 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"roudo/roudo"
+	"roudo/roudo_daemon"
 	"roudo/roudo_event"
 	"roudo/view"
+	"syscall"
 	"time"
 
 	"github.com/alexflint/go-filemutex"
 
-	"github.com/tidwall/buntdb"
-
 	"github.com/urfave/cli/v2"
 )
 
 func main() {
 	if err := run(); err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "roudo: %s\n", err)
+		os.Exit(1)
 	}
 }
 
@@ -26,9 +30,22 @@ func run() error {
 	app := &cli.App{
 		Name:  "roudo",
 		Usage: "労働監視くん",
+		Flags: globalFlags,
 		Commands: []*cli.Command{
 			kansiCommand,
+			daemonCommand,
 			viewCommand,
+			startCommand,
+			stopCommand,
+			breakCommand,
+			resumeCommand,
+			tagCommand,
+			statusCommand,
+			exportCommand,
+			importCommand,
+			historyCommand,
+			doctorCommand,
+			migrateCommand,
 		},
 	}
 	return app.Run(os.Args)
@@ -37,102 +54,275 @@ func run() error {
 var kansiCommand = &cli.Command{
 	Name:  "kansi",
 	Usage: "監視スタート",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "労働記録をメモリ上にだけ保存し、終了時に捨てる (--db :memory: と同じ)",
+		},
+		&cli.StringFlag{
+			Name:  "db",
+			Usage: "保存先のパス。:memory: を指定するとメモリ上にだけ保存する",
+		},
+	},
 	Action: func(c *cli.Context) error {
-		db, err := initDB()
+		db := c.String("db")
+		if c.Bool("dry-run") {
+			db = memoryDB
+		}
+		env, err := newRoudoEnvWithDB(c, db)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		defer db.Close()
-
-		logger := newLogger()
-		no := &roudo.MacNotificator{}
-
-		repo := roudo.NewRoudoReportRepository(db)
-		fm := newFileMutex()
-		reporter := roudo.NewRoudoReporter(repo, logger, no, fm)
+		defer env.Close()
+		return kansi(c, env)
+	},
+}
 
-		ws := roudo_event.NewAllWatchers(logger)
-		mgr := roudo.NewRoudoManager(reporter, ws, logger, 1*time.Second)
+// kansi は Ctrl-C や SIGTERM を受けるまで監視を続ける。終了時は Watcher を止め、記録を保存してから返る
+func kansi(c *cli.Context, env *roudoEnv) error {
+	ws := roudo_event.NewAllWatchers(env.logger)
+	mgr := roudo.NewRoudoManager(env.reporter, ws, env.logger, env.clock, env.cfg.Config)
 
-		return mgr.Kansi()
-	},
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return mgr.Kansi(ctx)
 }
 
 var viewCommand = &cli.Command{
-	Name:  "view",
-	Usage: "労働時間の一覧を表示",
+	Name:      "view",
+	Usage:     "労働時間の一覧を表示",
+	ArgsUsage: "[YYYY-MM]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "week",
+			Usage: "今週の労働時間を表示する",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "表示する期間の開始日 ex: 2024-03-01",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "表示する期間の終了日 ex: 2024-03-31 (default: 今日)",
+		},
+	},
 	Action: func(c *cli.Context) error {
-		db, err := initDB()
+		p, err := viewPeriod(c, time.Now())
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+		calendar, err := cfg.holidayCalendar()
 		if err != nil {
-			panic(err)
+			return err
+		}
+		logger, err := newLogger(cfg.Dir)
+		if err != nil {
+			return err
 		}
-		defer db.Close()
 
-		logger := newLogger()
-		no := &roudo.MacNotificator{}
-		repo := roudo.NewRoudoReportRepository(db)
-		fm := newFileMutex()
-		reporter := roudo.NewRoudoReporter(repo, logger, no, fm)
+		editor, closeEditor, err := openEditor(c)
+		if err != nil {
+			return err
+		}
+		defer closeEditor()
 
-		viewRepo := view.NewViewRepository(repo)
-		v := view.NewTUI(reporter, viewRepo, logger)
+		viewRepo := view.NewViewRepository(editor)
+		v := view.NewTUI(editor, viewRepo, calendar, roudo.NewValidator(cfg.ShiftDuration), logger)
 
-		return v.Do(c.Args().First())
+		return v.Do(p)
 	},
 }
 
-func initDB() (*buntdb.DB, error) {
-	dir, err := getRoudoDir()
+// viewPeriod は view の引数から表示する期間を決める。何も指定しなければ今月を表示する
+func viewPeriod(c *cli.Context, now time.Time) (view.Period, error) {
+	switch {
+	case c.IsSet("from") || c.IsSet("to"):
+		if !c.IsSet("from") {
+			return view.Period{}, fmt.Errorf("--to を指定する場合は --from も指定してください")
+		}
+		to := c.String("to")
+		if to == "" {
+			to = now.Format("2006-01-02")
+		}
+		return view.RangePeriod(c.String("from"), to)
+	case c.Bool("week"):
+		return view.WeekPeriod(now), nil
+	}
+
+	month := c.Args().First()
+	if month == "" {
+		month = now.Format("2006-01")
+	}
+	return view.MonthPeriod(month)
+}
+
+// roudoEnv は各コマンドで共通して使う設定や依存をまとめたもの
+type roudoEnv struct {
+	cfg       *config
+	closeRepo func() error
+	logger    *slog.Logger
+	clock     roudo.Clock
+	repo      roudo.RoudoReportRepository
+	audit     roudo.AuditLog
+	reporter  roudo.RoudoReporter
+}
+
+func newRoudoEnv(c *cli.Context) (*roudoEnv, error) {
+	return newRoudoEnvWithDB(c, "")
+}
+
+// newRoudoEnvWithDB は db が空でなければ storage の既定の保存先の代わりに db を使う
+func newRoudoEnvWithDB(c *cli.Context, db string) (*roudoEnv, error) {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	no, err := roudo.NewNotificator(cfg.Notificator)
 	if err != nil {
 		return nil, err
 	}
 
-	db, err := buntdb.Open(filepath.Join(dir, "roudo.db"))
+	if db != memoryDB {
+		if err := refuseWhileDaemonRunning(cfg.Dir); err != nil {
+			return nil, err
+		}
+	}
+	repo, closeRepo, err := openRepository(cfg.Dir, cfg.Storage, db)
+	if err != nil {
+		return nil, err
+	}
+
+	logger, err := newLogger(cfg.Dir)
 	if err != nil {
+		closeRepo()
 		return nil, err
 	}
-	return db, nil
+	// メモリ上の記録は他のプロセスと共有しないので、本番の kansi とロックを奪い合わないようにする
+	var mux roudo.Mutex
+	audit := roudo.NewFileAuditLog(auditLogPath(cfg.Dir))
+	if db == memoryDB {
+		logger = logger.With(slog.Bool("dry_run", true))
+		mux = roudo.NewProcessMutex()
+		audit = roudo.NewMemoryAuditLog()
+	} else {
+		fm, err := newFileMutex(cfg.Dir)
+		if err != nil {
+			closeRepo()
+			return nil, err
+		}
+		mux = fm
+	}
+	clock := roudo.NewSystemClock()
+	reporter := roudo.NewRoudoReporter(repo, audit, logger, no, mux, clock, cfg.Config)
+
+	return &roudoEnv{
+		cfg:       cfg,
+		closeRepo: closeRepo,
+		logger:    logger,
+		clock:     clock,
+		repo:      repo,
+		audit:     audit,
+		reporter:  reporter,
+	}, nil
+}
+
+func (e *roudoEnv) Close() error {
+	return e.closeRepo()
+}
+
+// refuseWhileDaemonRunning はデーモンが起動していればエラーを返す。デーモンが開いている記録を
+// 別のプロセスから開くと、互いの書き込みが見えないまま上書きし合うため
+func refuseWhileDaemonRunning(dir string) error {
+	if pid, err := roudo_daemon.ReadPID(dir); err == nil {
+		return fmt.Errorf("デーモンが起動中です (pid %d)。roudo daemon stop で止めてから実行してください", pid)
+	}
+	return nil
+}
+
+// openController はデーモンが起動していればデーモン経由で、そうでなければ記録を直接開いて操作する
+func openController(c *cli.Context) (roudo.RoudoController, func() error, error) {
+	client, env, err := dialOrOpen(c)
+	if err != nil {
+		return nil, nil, err
+	} else if client != nil {
+		return client, client.Close, nil
+	}
+	return env.reporter, env.Close, nil
 }
 
-func newLogger() *slog.Logger {
-	dir, err := getRoudoDir()
+// openEditor は openController と同じく、デーモンが起動していればデーモン経由で労働記録を読み書きする
+func openEditor(c *cli.Context) (roudo.RoudoEditor, func() error, error) {
+	client, env, err := dialOrOpen(c)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
+	} else if client != nil {
+		return client, client.Close, nil
 	}
+	return env.reporter, env.Close, nil
+}
+
+// dialOrOpen はデーモンが起動していればその接続を、そうでなければ記録を直接開いた roudoEnv を返す
+func dialOrOpen(c *cli.Context) (*roudo_daemon.Client, *roudoEnv, error) {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := roudo_daemon.Dial(cfg.Dir)
+	if err == nil {
+		return client, nil, nil
+	} else if !errors.Is(err, roudo_daemon.ErrNotRunning) {
+		return nil, nil, err
+	}
+
+	env, err := newRoudoEnv(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, env, nil
+}
+
+func auditLogPath(dir string) string {
+	return filepath.Join(dir, "audit.jsonl")
+}
+
+func newLogger(dir string) (*slog.Logger, error) {
 	logFile, err := os.OpenFile(filepath.Join(dir, "log.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("ログファイルを開けませんでした: %w", err)
 	}
 
 	return slog.New(
 		slog.NewJSONHandler(logFile, &slog.HandlerOptions{
 			Level: slog.LevelDebug,
 		}),
-	)
+	), nil
 }
 
-func newFileMutex() *filemutex.FileMutex {
-	dir, err := getRoudoDir()
-	if err != nil {
-		panic(err)
-	}
-
+func newFileMutex(dir string) (*filemutex.FileMutex, error) {
 	mux, err := filemutex.New(filepath.Join(dir, "roudo.lock"))
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("ロックファイルを開けませんでした: %w", err)
 	}
-	return mux
+	return mux, nil
 }
 
-func getRoudoDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
+func getRoudoDir(dir string) (string, error) {
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".roudo")
 	}
 
-	dir := filepath.Join(home, ".roudo")
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		if err := os.Mkdir(dir, 0755); err != nil {
+		if err := os.MkdirAll(dir, 0755); err != nil {
 			return "", err
 		}
 	}
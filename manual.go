@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"roudo/roudo"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+var atFlag = &cli.StringFlag{
+	Name:  "at",
+	Usage: "打刻する時刻 (HH:mm または YYYY-MM-DD HH:mm)。省略時は現在時刻",
+}
+
+var startCommand = &cli.Command{
+	Name:   "start",
+	Usage:  "手動で労働を開始",
+	Flags:  []cli.Flag{atFlag},
+	Action: manualAction(roudo.RoudoController.StartWorking, "労働を開始しました"),
+}
+
+var stopCommand = &cli.Command{
+	Name:   "stop",
+	Usage:  "手動で労働を終了",
+	Flags:  []cli.Flag{atFlag},
+	Action: manualAction(roudo.RoudoController.FinishWorking, "労働を終了しました"),
+}
+
+var breakCommand = &cli.Command{
+	Name:   "break",
+	Usage:  "手動で休憩を開始",
+	Flags:  []cli.Flag{atFlag},
+	Action: manualAction(roudo.RoudoController.StartBreaking, "休憩を開始しました"),
+}
+
+var resumeCommand = &cli.Command{
+	Name:   "resume",
+	Usage:  "手動で休憩を終了",
+	Flags:  []cli.Flag{atFlag},
+	Action: manualAction(roudo.RoudoController.FinishBreaking, "休憩を終了しました"),
+}
+
+var tagCommand = &cli.Command{
+	Name:      "tag",
+	Usage:     "進行中の労働にプロジェクトを記録",
+	ArgsUsage: "<project>",
+	Flags: []cli.Flag{
+		atFlag,
+		&cli.StringFlag{
+			Name:  "task",
+			Usage: "タスク名",
+		},
+		&cli.BoolFlag{
+			Name:  "clear",
+			Usage: "プロジェクトの記録を止める",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		project := c.Args().First()
+		if project == "" && !c.Bool("clear") {
+			return fmt.Errorf("プロジェクトを指定してください")
+		}
+		if project != "" && c.Bool("clear") {
+			return fmt.Errorf("--clear とプロジェクトは同時に指定できません")
+		}
+
+		message := "プロジェクト " + project + " を記録します"
+		if c.Bool("clear") {
+			message = "プロジェクトの記録を止めました"
+		}
+		return manualAction(func(r roudo.RoudoController, at time.Time) error {
+			return r.Tag(at, project, c.String("task"))
+		}, message)(c)
+	},
+}
+
+func manualAction(do func(roudo.RoudoController, time.Time) error, message string) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		at, err := parseAt(c.String("at"), time.Now())
+		if err != nil {
+			return err
+		}
+
+		ctrl, closeCtrl, err := openController(c)
+		if err != nil {
+			return err
+		}
+		defer closeCtrl()
+
+		if err := do(ctrl, at); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.App.Writer, "%s (%s)\n", message, at.Format("2006-01-02 15:04"))
+		return nil
+	}
+}
+
+// parseAt は --at の値を解釈する。HH:mm の場合は now 以前で直近のその時刻とみなす
+func parseAt(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return now, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, now.Location()); err == nil {
+		if t.After(now) {
+			return time.Time{}, fmt.Errorf("未来の時刻は指定できません: %s", s)
+		}
+		return t, nil
+	}
+
+	hm, err := time.ParseInLocation("15:04", s, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("時刻の形式が不正です ex: 09:15, 2024-03-01 09:15")
+	}
+	t := time.Date(now.Year(), now.Month(), now.Day(), hm.Hour(), hm.Minute(), 0, 0, now.Location())
+	if t.After(now) {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t, nil
+}
@@ -0,0 +1,131 @@
+package roudo
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"roudo/roudo_event"
+	"time"
+)
+
+// Activity は自動で推定した作業内容の区間。EndAt が nil なら労働の終了まで続く
+type Activity struct {
+	Label   string     `json:"label"`
+	StartAt time.Time  `json:"start_at"`
+	EndAt   *time.Time `json:"end_at,omitempty"`
+}
+
+type ActivityTarget string
+
+const (
+	// ActivityTargetWindow はアクティブなウィンドウのタイトル
+	ActivityTargetWindow = ActivityTarget("window")
+	// ActivityTargetCwd はアクティブなウィンドウで前面にいるプロセスのカレントディレクトリ
+	ActivityTargetCwd = ActivityTarget("cwd")
+	// ActivityTargetGit は ActivityTargetCwd を含む git リポジトリのルート。最近更新したファイルの git リポジトリは見ない
+	ActivityTargetGit = ActivityTarget("git")
+)
+
+// ActivityRule は ActivityContext の Target が Pattern に一致した場合に Label を付けるルール。
+// Label には $1 などで Pattern のサブマッチを使える
+type ActivityRule struct {
+	Target  ActivityTarget
+	Pattern *regexp.Regexp
+	Label   string
+}
+
+func NewActivityRule(target, pattern, label string) (ActivityRule, error) {
+	t := ActivityTarget(target)
+	switch t {
+	case ActivityTargetWindow, ActivityTargetCwd, ActivityTargetGit:
+	default:
+		return ActivityRule{}, fmt.Errorf("未対応の対象です: %s", target)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ActivityRule{}, err
+	}
+	if label == "" {
+		return ActivityRule{}, fmt.Errorf("label を指定してください")
+	}
+	return ActivityRule{Target: t, Pattern: re, Label: label}, nil
+}
+
+func (rule ActivityRule) match(ctx roudo_event.ActivityContext) (string, bool) {
+	var src string
+	switch rule.Target {
+	case ActivityTargetWindow:
+		src = ctx.WindowTitle
+	case ActivityTargetCwd:
+		src = ctx.WorkingDir
+	case ActivityTargetGit:
+		src = ctx.GitRoot
+	}
+	if src == "" {
+		return "", false
+	}
+	idx := rule.Pattern.FindStringSubmatchIndex(src)
+	if idx == nil {
+		return "", false
+	}
+	return string(rule.Pattern.ExpandString(nil, rule.Label, src, idx)), true
+}
+
+// MatchActivity は最初に一致したルールのラベルを返す。一致しなければ空文字を返す
+func MatchActivity(rules []ActivityRule, ctx roudo_event.ActivityContext) string {
+	for _, rule := range rules {
+		if label, ok := rule.match(ctx); ok {
+			return label
+		}
+	}
+	return ""
+}
+
+// activityAt は現在の作業内容が label と異なれば at で閉じ、label が空でなければ at から新しい区間を始める
+func (r *Roudo) activityAt(at time.Time, label string) bool {
+	if n := len(r.Activities); n != 0 && r.Activities[n-1].EndAt == nil {
+		if r.Activities[n-1].Label == label {
+			return false
+		}
+		if r.Activities[n-1].StartAt.Before(at) {
+			r.Activities[n-1].EndAt = &at
+		} else {
+			r.Activities = r.Activities[:n-1]
+		}
+	} else if label == "" {
+		return false
+	}
+	if label != "" {
+		r.Activities = append(r.Activities, Activity{Label: label, StartAt: at})
+	}
+	return true
+}
+
+func (r *roudoReport) HandleActivityContext(ctx roudo_event.ActivityContext) error {
+	r.lock(AuditSourceAuto)
+	defer r.unlock()
+
+	s, err := r.repo.GetCurrentState()
+	if err != nil {
+		return err
+	}
+	if s != RoudoStateWorking {
+		return nil
+	}
+
+	label := MatchActivity(r.activityRules, ctx)
+	r.logger.Debug("handle activity context", slog.String("label", label), slog.String("window", ctx.WindowTitle), slog.String("cwd", ctx.WorkingDir))
+
+	t := NewRoudoTime(r.clock.Now(), r.shiftDuration)
+	rs, err := r.repo.GetRoudoReport(t.ShiftedDate())
+	if err != nil {
+		return err
+	}
+	if len(rs) == 0 || rs[len(rs)-1].EndAt != nil {
+		return nil
+	}
+	if !rs[len(rs)-1].activityAt(*t.Time(), label) {
+		return nil
+	}
+	return r.saveRoudoReport(t.ShiftedDate(), rs)
+}
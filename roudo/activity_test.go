@@ -0,0 +1,159 @@
+package roudo
+
+import (
+	"reflect"
+	"roudo/roudo_event"
+	"testing"
+	"time"
+)
+
+func TestMatchActivity(t *testing.T) {
+	rule := func(target, pattern, label string) ActivityRule {
+		t.Helper()
+		r, err := NewActivityRule(target, pattern, label)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	rules := []ActivityRule{
+		rule("window", `^Zoom`, "meeting"),
+		rule("git", `/src/([^/]+)$`, "dev:$1"),
+		rule("cwd", `^/home/me/docs`, "docs"),
+		rule("window", `- (\w+) - Slack$`, "slack:${1}"),
+	}
+	tests := []struct {
+		name string
+		ctx  roudo_event.ActivityContext
+		want string
+	}{
+		{
+			name: "先に書いたルールが優先される",
+			ctx:  roudo_event.ActivityContext{WindowTitle: "Zoom Meeting", GitRoot: "/home/me/src/roudo"},
+			want: "meeting",
+		},
+		{
+			name: "サブマッチをラベルに使う",
+			ctx:  roudo_event.ActivityContext{WindowTitle: "vim", WorkingDir: "/home/me/src/roudo/view", GitRoot: "/home/me/src/roudo"},
+			want: "dev:roudo",
+		},
+		{
+			name: "git リポジトリの外なら cwd で判定する",
+			ctx:  roudo_event.ActivityContext{WorkingDir: "/home/me/docs/2024"},
+			want: "docs",
+		},
+		{
+			name: "${1} の形式のサブマッチ",
+			ctx:  roudo_event.ActivityContext{WindowTitle: "general - acme - Slack"},
+			want: "slack:acme",
+		},
+		{
+			name: "どのルールにも一致しない",
+			ctx:  roudo_event.ActivityContext{WindowTitle: "Firefox", WorkingDir: "/tmp"},
+			want: "",
+		},
+		{
+			name: "空の対象には一致しない",
+			ctx:  roudo_event.ActivityContext{},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchActivity(rules, tt.ctx); got != tt.want {
+				t.Errorf("MatchActivity = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, tt := range []struct{ target, pattern, label string }{
+		{"title", `.`, "x"},
+		{"window", `(`, "x"},
+		{"window", `.`, ""},
+	} {
+		if _, err := NewActivityRule(tt.target, tt.pattern, tt.label); err == nil {
+			t.Errorf("NewActivityRule(%q, %q, %q) にエラーがありません", tt.target, tt.pattern, tt.label)
+		}
+	}
+}
+
+func TestActivityAt(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 3, 1, hour, min, 0, 0, time.Local)
+	}
+	ptr := func(t time.Time) *time.Time {
+		return &t
+	}
+	tests := []struct {
+		name        string
+		activities  []Activity
+		at          time.Time
+		label       string
+		wantChanged bool
+		want        []Activity
+	}{
+		{
+			name:        "最初の作業内容を始める",
+			at:          at(9, 0),
+			label:       "dev",
+			wantChanged: true,
+			want:        []Activity{{Label: "dev", StartAt: at(9, 0)}},
+		},
+		{
+			name:  "作業内容がなく一致するルールもなければ何もしない",
+			at:    at(9, 0),
+			label: "",
+			want:  nil,
+		},
+		{
+			name:       "同じ作業内容が続いていれば何もしない",
+			activities: []Activity{{Label: "dev", StartAt: at(9, 0)}},
+			at:         at(10, 0),
+			label:      "dev",
+			want:       []Activity{{Label: "dev", StartAt: at(9, 0)}},
+		},
+		{
+			name:        "作業内容が変われば閉じて次を始める",
+			activities:  []Activity{{Label: "dev", StartAt: at(9, 0)}},
+			at:          at(10, 0),
+			label:       "meeting",
+			wantChanged: true,
+			want:        []Activity{{Label: "dev", StartAt: at(9, 0), EndAt: ptr(at(10, 0))}, {Label: "meeting", StartAt: at(10, 0)}},
+		},
+		{
+			name:        "一致するルールがなくなれば閉じるだけ",
+			activities:  []Activity{{Label: "dev", StartAt: at(9, 0)}},
+			at:          at(10, 0),
+			label:       "",
+			wantChanged: true,
+			want:        []Activity{{Label: "dev", StartAt: at(9, 0), EndAt: ptr(at(10, 0))}},
+		},
+		{
+			name:        "同じ時刻に切り替わった作業内容は長さ0の区間を残さない",
+			activities:  []Activity{{Label: "dev", StartAt: at(9, 0), EndAt: ptr(at(9, 30))}, {Label: "docs", StartAt: at(10, 0)}},
+			at:          at(10, 0),
+			label:       "meeting",
+			wantChanged: true,
+			want:        []Activity{{Label: "dev", StartAt: at(9, 0), EndAt: ptr(at(9, 30))}, {Label: "meeting", StartAt: at(10, 0)}},
+		},
+		{
+			name:        "閉じた作業内容の後に新しく始める",
+			activities:  []Activity{{Label: "dev", StartAt: at(9, 0), EndAt: ptr(at(9, 30))}},
+			at:          at(10, 0),
+			label:       "dev",
+			wantChanged: true,
+			want:        []Activity{{Label: "dev", StartAt: at(9, 0), EndAt: ptr(at(9, 30))}, {Label: "dev", StartAt: at(10, 0)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Roudo{Activities: tt.activities}
+			if changed := r.activityAt(tt.at, tt.label); changed != tt.wantChanged {
+				t.Errorf("activityAt = %v, want %v", changed, tt.wantChanged)
+			}
+			if !reflect.DeepEqual(r.Activities, tt.want) {
+				t.Errorf("activities = %+v, want %+v", r.Activities, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package roudo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditSource は労働記録を書き換えたのが何かを表す
+type AuditSource string
+
+const (
+	// AuditSourceAuto は kansi による自動判定
+	AuditSourceAuto = AuditSource("auto")
+	// AuditSourceTUI は view での手動編集
+	AuditSourceTUI = AuditSource("tui")
+	// AuditSourceImport は import コマンドでの取り込み
+	AuditSourceImport = AuditSource("import")
+	// AuditSourceCLI は start や tag などのコマンドでの打刻
+	AuditSourceCLI = AuditSource("cli")
+	// AuditSourceDoctor は doctor --fix による修復
+	AuditSourceDoctor = AuditSource("doctor")
+	// AuditSourceMigrate は migrate コマンドでの保存先の移行
+	AuditSourceMigrate = AuditSource("migrate")
+)
+
+// AuditEntry は1日分の労働記録の書き換え1回分。Before と After は書き換え前後の []Roudo の JSON
+type AuditEntry struct {
+	At     time.Time       `json:"at"`
+	Source AuditSource     `json:"source"`
+	Date   Date            `json:"date"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+func (e AuditEntry) BeforeRoudos() ([]Roudo, error) {
+	return unmarshalRoudos(e.Before)
+}
+
+func (e AuditEntry) AfterRoudos() ([]Roudo, error) {
+	return unmarshalRoudos(e.After)
+}
+
+func unmarshalRoudos(bs json.RawMessage) ([]Roudo, error) {
+	var rs []Roudo
+	if len(bs) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(bs, &rs); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// sameRoudos は a と b が保存した時に同じ内容になるかを返す
+func sameRoudos(a, b []Roudo) (bool, error) {
+	bsA, err := marshalRoudos(a)
+	if err != nil {
+		return false, err
+	}
+	bsB, err := marshalRoudos(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(bsA, bsB), nil
+}
+
+// newAuditEntry は書き換え前後が同じであれば false を返す
+func newAuditEntry(at time.Time, source AuditSource, date Date, before, after []Roudo) (AuditEntry, bool, error) {
+	b, err := marshalRoudos(before)
+	if err != nil {
+		return AuditEntry{}, false, err
+	}
+	a, err := marshalRoudos(after)
+	if err != nil {
+		return AuditEntry{}, false, err
+	}
+	if bytes.Equal(a, b) {
+		return AuditEntry{}, false, nil
+	}
+	return AuditEntry{At: at, Source: source, Date: date, Before: b, After: a}, true, nil
+}
+
+// marshalRoudos は空の記録を nil と [] で区別しないように null にする
+func marshalRoudos(rs []Roudo) (json.RawMessage, error) {
+	if len(rs) == 0 {
+		return json.RawMessage("null"), nil
+	}
+	return json.Marshal(rs)
+}
+
+// AuditLog は労働記録の書き換えを追記のみで残す
+type AuditLog interface {
+	Append(e AuditEntry) error
+	// List は date の書き換えを古い順に返す
+	List(date Date) ([]AuditEntry, error)
+}
+
+// NewFileAuditLog は path に1行1エントリの JSON Lines で追記する AuditLog を返す
+func NewFileAuditLog(path string) AuditLog {
+	return &fileAuditLog{path: path}
+}
+
+type fileAuditLog struct {
+	path string
+}
+
+func (l *fileAuditLog) Append(e AuditEntry) error {
+	bs, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(bs, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (l *fileAuditLog) List(date Date) ([]AuditEntry, error) {
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	dec := json.NewDecoder(f)
+	for {
+		var e AuditEntry
+		if err := dec.Decode(&e); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("監査ログの読み込みに失敗しました: %s: %w", l.path, err)
+		}
+		if e.Date == date {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// NewMemoryAuditLog はメモリ上にのみ残す AuditLog を返す。テストや dry-run で使う
+func NewMemoryAuditLog() AuditLog {
+	return &memoryAuditLog{}
+}
+
+type memoryAuditLog struct {
+	mu      sync.RWMutex
+	entries []AuditEntry
+}
+
+func (l *memoryAuditLog) Append(e AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, e)
+	return nil
+}
+
+func (l *memoryAuditLog) List(date Date) ([]AuditEntry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var entries []AuditEntry
+	for _, e := range l.entries {
+		if e.Date == date {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
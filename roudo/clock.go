@@ -0,0 +1,51 @@
+package roudo
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock は現在時刻と待機を抽象化したもの。シミュレーションでは FakeClock に差し替える
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Mutex は roudoReport の更新を排他するロック。filemutex.FileMutex を満たす
+type Mutex interface {
+	Lock() error
+	Unlock() error
+}
+
+// NewProcessMutex はプロセス内でのみ排他する Mutex を返す
+func NewProcessMutex() Mutex {
+	return &processMutex{}
+}
+
+type processMutex struct {
+	mu sync.Mutex
+}
+
+func (m *processMutex) Lock() error {
+	m.mu.Lock()
+	return nil
+}
+
+func (m *processMutex) Unlock() error {
+	m.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,79 @@
+package roudo
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExitAction は kansi が終了する時に進行中の労働をどうするか
+type ExitAction string
+
+const (
+	// ExitActionKeep は何もしない。次に起動した時に最終イベント時刻から判定する
+	ExitActionKeep = ExitAction("keep")
+	// ExitActionBreak は労働中であれば最終イベント時刻から休憩にする
+	ExitActionBreak = ExitAction("break")
+	// ExitActionFinish は最終イベント時刻で労働を終了する
+	ExitActionFinish = ExitAction("finish")
+)
+
+type Config struct {
+	// 日付の切り替わりを 0:00 からずらす時間。5h なら 5:00 で日付が変わる
+	ShiftDuration time.Duration
+	// 最終イベントからこの時間が経過すると休憩開始とみなす
+	StartBreakInterval time.Duration
+	// 最終イベントからこの時間が経過すると労働終了とみなす
+	FinishWorkingInterval time.Duration
+	// 画面ロック・スリープがこの時間以上続くと、ロックした時刻で労働終了とみなす
+	LockFinishWorkingInterval time.Duration
+	// Kansi のポーリング間隔
+	PollingInterval time.Duration
+	// 労働中に入力イベントをまとめて保存する間隔。0 なら毎回保存する
+	EventBatchInterval time.Duration
+	// kansi が終了する時に進行中の労働をどうするか
+	OnExit ExitAction
+	// アクティブなウィンドウや作業ディレクトリから作業内容を推定するルール。先に書いたものが優先される
+	ActivityRules []ActivityRule
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ShiftDuration:             5 * time.Hour,
+		StartBreakInterval:        35 * time.Minute,
+		FinishWorkingInterval:     4 * time.Hour,
+		LockFinishWorkingInterval: 2 * time.Hour,
+		PollingInterval:           1 * time.Second,
+		EventBatchInterval:        10 * time.Second,
+		OnExit:                    ExitActionKeep,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.ShiftDuration < 0 || c.ShiftDuration >= 24*time.Hour {
+		return fmt.Errorf("shift_duration は 0 以上 24h 未満で指定してください: %s", c.ShiftDuration)
+	}
+	if c.StartBreakInterval <= 0 {
+		return fmt.Errorf("start_break_interval は正の値で指定してください: %s", c.StartBreakInterval)
+	}
+	if c.FinishWorkingInterval <= c.StartBreakInterval {
+		return fmt.Errorf("finish_working_interval は start_break_interval より長く指定してください: %s <= %s", c.FinishWorkingInterval, c.StartBreakInterval)
+	}
+	if c.LockFinishWorkingInterval <= 0 {
+		return fmt.Errorf("lock_finish_working_interval は正の値で指定してください: %s", c.LockFinishWorkingInterval)
+	}
+	if c.PollingInterval <= 0 {
+		return fmt.Errorf("polling_interval は正の値で指定してください: %s", c.PollingInterval)
+	}
+	if c.PollingInterval > c.StartBreakInterval {
+		return fmt.Errorf("polling_interval は start_break_interval 以下で指定してください: %s > %s", c.PollingInterval, c.StartBreakInterval)
+	}
+	switch c.OnExit {
+	case ExitActionKeep, ExitActionBreak, ExitActionFinish:
+	default:
+		return fmt.Errorf("on_exit は keep, break, finish のいずれかで指定してください: %s", c.OnExit)
+	}
+	if c.EventBatchInterval < 0 || c.EventBatchInterval >= c.StartBreakInterval {
+		return fmt.Errorf("event_batch_interval は 0 以上 start_break_interval 未満で指定してください: %s", c.EventBatchInterval)
+	}
+	return nil
+}
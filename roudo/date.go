@@ -28,3 +28,15 @@ func (rt RoudoTime) IsOvernight(before RoudoTime) bool {
 }
 
 type Date string
+
+func (d Date) Time() (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", string(d), time.Local)
+}
+
+// month は 2006-01 形式の年月を返す
+func (d Date) month() string {
+	if len(d) < len("2006-01") {
+		return string(d)
+	}
+	return string(d)[:len("2006-01")]
+}
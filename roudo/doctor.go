@@ -0,0 +1,234 @@
+package roudo
+
+import (
+	"fmt"
+	"time"
+)
+
+type Severity string
+
+const (
+	// SeverityError は労働時間の集計が誤っている不整合
+	SeverityError = Severity("error")
+	// SeverityWarning は集計には影響しないか、影響が小さい不整合
+	SeverityWarning = Severity("warning")
+)
+
+// Finding は Doctor が見つけた不整合1件。Date が空のものは current_state の不整合
+type Finding struct {
+	Date     Date
+	Severity Severity
+	Message  string
+	// Fixable は Doctor(true) で自動的に直せるもの。Fixed は実際に直したもの
+	Fixable bool
+	Fixed   bool
+}
+
+func (r *roudoReport) Doctor(fix bool) ([]Finding, error) {
+	r.lock(AuditSourceDoctor)
+	defer r.unlock()
+
+	dates, err := r.repo.ListDates()
+	if err != nil {
+		return nil, err
+	}
+	state, err := r.repo.GetCurrentState()
+	if err != nil {
+		return nil, err
+	}
+	rt, err := r.lastEventAt()
+	if err != nil {
+		return nil, err
+	}
+	var lastEventAt *time.Time
+	var currentDate Date
+	if rt != nil {
+		lastEventAt = rt.Time()
+		// 進行中の労働は最終イベント時刻の日付の最後の労働
+		if state != RoudoStateOff {
+			currentDate = rt.ShiftedDate()
+		}
+	}
+
+	validator := NewValidator(r.shiftDuration)
+	var findings []Finding
+	var current []Roudo
+	for _, date := range dates {
+		rs, err := r.repo.GetRoudoReport(date)
+		if err != nil {
+			return nil, err
+		}
+		d := &reportDoctor{date: date, validator: validator, current: date == currentDate, lastEventAt: lastEventAt}
+		repaired := d.diagnose(CloneRoudos(rs))
+		if fix && len(d.findings) != 0 {
+			if err := r.saveRoudoReport(date, repaired); err != nil {
+				d.findings = append(d.findings, Finding{Date: date, Severity: SeverityError, Message: "修復できませんでした: " + err.Error()})
+				repaired = rs
+			} else {
+				for i := range d.findings {
+					d.findings[i].Fixed = true
+				}
+			}
+		}
+		findings = append(findings, d.findings...)
+
+		// 自動で直せない不整合は、直した後の記録で調べる
+		for _, i := range validator.Validate(date, repaired) {
+			severity := SeverityError
+			if i.Warning {
+				severity = SeverityWarning
+			}
+			findings = append(findings, Finding{Date: date, Severity: severity, Message: i.Message})
+		}
+		if date == currentDate {
+			current = repaired
+		}
+	}
+
+	stateFindings, repairedState := diagnoseState(state, lastEventAt, current)
+	if fix && len(stateFindings) != 0 {
+		if err := r.repo.SaveCurrentState(repairedState); err != nil {
+			return nil, err
+		}
+		for i := range stateFindings {
+			stateFindings[i].Fixed = true
+		}
+	}
+	return append(findings, stateFindings...), nil
+}
+
+// reportDoctor は1日分の労働記録を調べて、直せる不整合を直す
+type reportDoctor struct {
+	date        Date
+	validator   *Validator
+	current     bool
+	lastEventAt *time.Time
+	findings    []Finding
+}
+
+func (d *reportDoctor) report(severity Severity, format string, args ...any) {
+	d.findings = append(d.findings, Finding{Date: d.date, Severity: severity, Message: fmt.Sprintf(format, args...), Fixable: true})
+}
+
+func (d *reportDoctor) diagnose(rs []Roudo) []Roudo {
+	for i := range rs {
+		s := &rs[i]
+		d.anchorZeroDates(s)
+
+		open := d.current && i == len(rs)-1
+		if s.EndAt == nil && !open && s.StartAt != nil {
+			endAt := d.lastActivityAt(*s, i == len(rs)-1)
+			d.report(SeverityError, "労働 %s が終了していません。%s に終了します", sessionLabel(*s), timeLabel(&endAt))
+			s.EndAt = &endAt
+		}
+
+		var breaks []Break
+		for j, b := range s.Breaks {
+			bl := timeRangeLabel(&b.StartAt, b.EndAt)
+			switch {
+			case b.StartAt.IsZero():
+				d.report(SeverityWarning, "労働 %s に開始時刻のない休憩があります。削除します", sessionLabel(*s))
+				continue
+			case b.EndAt == nil && (s.EndAt != nil || j != len(s.Breaks)-1):
+				d.report(SeverityWarning, "休憩 %s が終了していません。削除します", bl)
+				continue
+			case b.EndAt != nil && b.EndAt.Equal(b.StartAt):
+				d.report(SeverityWarning, "休憩 %s の長さが0です。削除します", bl)
+				continue
+			}
+			breaks = append(breaks, b)
+		}
+		if len(breaks) != len(s.Breaks) {
+			s.Breaks = breaks
+		}
+	}
+	return rs
+}
+
+// anchorZeroDates は time.Parse("15:04") で保存された 0000-01-01 の時刻を、その日の時刻に直す
+func (d *reportDoctor) anchorZeroDates(s *Roudo) {
+	found := false
+	anchor := func(t *time.Time) {
+		if t == nil || t.Year() != 0 {
+			return
+		}
+		a, err := d.validator.ParseTime(d.date, t.Format("15:04"))
+		if err != nil {
+			return
+		}
+		*t = a
+		found = true
+	}
+
+	label := sessionLabel(*s)
+	anchor(s.StartAt)
+	anchor(s.EndAt)
+	for i := range s.Breaks {
+		anchor(&s.Breaks[i].StartAt)
+		anchor(s.Breaks[i].EndAt)
+	}
+	for i := range s.Tags {
+		anchor(&s.Tags[i].StartAt)
+		anchor(s.Tags[i].EndAt)
+	}
+	for i := range s.Activities {
+		anchor(&s.Activities[i].StartAt)
+		anchor(s.Activities[i].EndAt)
+	}
+	if found {
+		d.report(SeverityError, "労働 %s に日付のない時刻があります。%s の時刻にします", label, d.date)
+	}
+}
+
+// lastActivityAt は労働の中で記録されている最後の時刻を返す。最後の労働であれば最終イベント時刻も含める
+func (d *reportDoctor) lastActivityAt(s Roudo, last bool) time.Time {
+	latest := *s.StartAt
+	consider := func(t *time.Time) {
+		if t != nil && t.After(latest) {
+			latest = *t
+		}
+	}
+	for _, b := range s.Breaks {
+		consider(&b.StartAt)
+		consider(b.EndAt)
+	}
+	for _, t := range s.Tags {
+		consider(&t.StartAt)
+		consider(t.EndAt)
+	}
+	for _, a := range s.Activities {
+		consider(&a.StartAt)
+		consider(a.EndAt)
+	}
+	if last && d.lastEventAt != nil && NewRoudoTime(*d.lastEventAt, d.validator.shiftDuration).ShiftedDate() == d.date {
+		consider(d.lastEventAt)
+	}
+	return latest
+}
+
+// diagnoseState は current_state と進行中の労働 current が食い違っていないかを調べ、直した後の状態を返す
+func diagnoseState(state RoudoState, lastEventAt *time.Time, current []Roudo) ([]Finding, RoudoState) {
+	if state == RoudoStateOff {
+		return nil, state
+	}
+	finding := func(severity Severity, format string, args ...any) []Finding {
+		return []Finding{{Severity: severity, Message: fmt.Sprintf(format, args...), Fixable: true}}
+	}
+
+	if lastEventAt == nil {
+		return finding(SeverityError, "current_state が %s なのに最終イベント時刻がありません。労働外にします", state), RoudoStateOff
+	}
+	if len(current) == 0 || current[len(current)-1].EndAt != nil {
+		return finding(SeverityError, "current_state が %s なのに進行中の労働がありません。労働外にします", state), RoudoStateOff
+	}
+
+	breaks := current[len(current)-1].Breaks
+	breaking := len(breaks) != 0 && breaks[len(breaks)-1].EndAt == nil
+	switch {
+	case state == RoudoStateWorking && breaking:
+		return finding(SeverityWarning, "current_state が working なのに休憩が終了していません。休憩中にします"), RoudoStateBreaking
+	case state == RoudoStateBreaking && !breaking:
+		return finding(SeverityWarning, "current_state が breaking なのに進行中の休憩がありません。労働中にします"), RoudoStateWorking
+	}
+	return nil, state
+}
@@ -0,0 +1,283 @@
+package roudo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDoctor(t *testing.T) {
+	at := func(day, hour, min int) *time.Time {
+		t := time.Date(2024, 3, day, hour, min, 0, 0, time.Local)
+		return &t
+	}
+	// time.Parse("15:04") で保存された日付のない時刻
+	zero := func(hour, min int) *time.Time {
+		t := time.Date(0, 1, 1, hour, min, 0, 0, time.Local)
+		return &t
+	}
+	now := *at(2, 12, 0)
+
+	tests := []struct {
+		name        string
+		stored      map[Date][]Roudo
+		state       RoudoState
+		lastEventAt *time.Time
+		fix         bool
+		want        []Finding
+		// 修復した日の記録。修復しない日は stored のまま
+		wantReports map[Date][]Roudo
+		wantState   RoudoState
+	}{
+		{
+			name: "不整合がなければ何も見つからない",
+			stored: map[Date][]Roudo{
+				"2024-03-01": {{StartAt: at(1, 9, 0), EndAt: at(1, 18, 0), Breaks: []Break{{StartAt: *at(1, 12, 0), EndAt: at(1, 13, 0)}}}},
+				"2024-03-02": {{StartAt: at(2, 9, 0)}},
+			},
+			state:       RoudoStateWorking,
+			lastEventAt: at(2, 11, 0),
+			fix:         true,
+			wantState:   RoudoStateWorking,
+		},
+		{
+			name: "終了していない過去の労働は --fix なしでは直さない",
+			stored: map[Date][]Roudo{
+				"2024-03-01": {{StartAt: at(1, 9, 0), Tags: []Tag{{Project: "roudo", StartAt: *at(1, 9, 0), EndAt: at(1, 15, 0)}}}},
+			},
+			state: RoudoStateOff,
+			want: []Finding{
+				{Date: "2024-03-01", Severity: SeverityError, Message: "労働 09:00~--:-- が終了していません。15:00 に終了します", Fixable: true},
+			},
+			wantState: RoudoStateOff,
+		},
+		{
+			name: "終了していない過去の労働を記録されている最後の時刻で終了する",
+			stored: map[Date][]Roudo{
+				"2024-03-01": {{StartAt: at(1, 9, 0), Tags: []Tag{{Project: "roudo", StartAt: *at(1, 9, 0), EndAt: at(1, 15, 0)}}}},
+			},
+			state: RoudoStateOff,
+			fix:   true,
+			want: []Finding{
+				{Date: "2024-03-01", Severity: SeverityError, Message: "労働 09:00~--:-- が終了していません。15:00 に終了します", Fixable: true, Fixed: true},
+			},
+			wantReports: map[Date][]Roudo{
+				"2024-03-01": {{StartAt: at(1, 9, 0), EndAt: at(1, 15, 0), Tags: []Tag{{Project: "roudo", StartAt: *at(1, 9, 0), EndAt: at(1, 15, 0)}}}},
+			},
+			wantState: RoudoStateOff,
+		},
+		{
+			name: "終了していない休憩と長さが0の休憩を削除する",
+			stored: map[Date][]Roudo{
+				"2024-03-01": {{StartAt: at(1, 9, 0), EndAt: at(1, 18, 0), Breaks: []Break{
+					{StartAt: *at(1, 12, 0)},
+					{StartAt: *at(1, 15, 0), EndAt: at(1, 15, 30)},
+					{StartAt: *at(1, 16, 0), EndAt: at(1, 16, 0)},
+				}}},
+			},
+			state: RoudoStateOff,
+			fix:   true,
+			want: []Finding{
+				{Date: "2024-03-01", Severity: SeverityWarning, Message: "休憩 12:00~--:-- が終了していません。削除します", Fixable: true, Fixed: true},
+				{Date: "2024-03-01", Severity: SeverityWarning, Message: "休憩 16:00~16:00 の長さが0です。削除します", Fixable: true, Fixed: true},
+			},
+			wantReports: map[Date][]Roudo{
+				"2024-03-01": {{StartAt: at(1, 9, 0), EndAt: at(1, 18, 0), Breaks: []Break{{StartAt: *at(1, 15, 0), EndAt: at(1, 15, 30)}}}},
+			},
+			wantState: RoudoStateOff,
+		},
+		{
+			name: "日付のない時刻をその日の時刻にする",
+			stored: map[Date][]Roudo{
+				"2024-03-01": {{StartAt: zero(9, 0), EndAt: zero(18, 0), Breaks: []Break{{StartAt: *zero(12, 0), EndAt: zero(13, 0)}}}},
+			},
+			state: RoudoStateOff,
+			fix:   true,
+			want: []Finding{
+				{Date: "2024-03-01", Severity: SeverityError, Message: "労働 09:00~18:00 に日付のない時刻があります。2024-03-01 の時刻にします", Fixable: true, Fixed: true},
+			},
+			wantReports: map[Date][]Roudo{
+				"2024-03-01": {{StartAt: at(1, 9, 0), EndAt: at(1, 18, 0), Breaks: []Break{{StartAt: *at(1, 12, 0), EndAt: at(1, 13, 0)}}}},
+			},
+			wantState: RoudoStateOff,
+		},
+		{
+			name: "進行中の休憩がないのに breaking なら労働中にする",
+			stored: map[Date][]Roudo{
+				"2024-03-02": {{StartAt: at(2, 9, 0)}},
+			},
+			state:       RoudoStateBreaking,
+			lastEventAt: at(2, 11, 0),
+			fix:         true,
+			want: []Finding{
+				{Severity: SeverityWarning, Message: "current_state が breaking なのに進行中の休憩がありません。労働中にします", Fixable: true, Fixed: true},
+			},
+			wantState: RoudoStateWorking,
+		},
+		{
+			name: "進行中の労働がないのに working なら労働外にする",
+			stored: map[Date][]Roudo{
+				"2024-03-02": {{StartAt: at(2, 9, 0), EndAt: at(2, 11, 0)}},
+			},
+			state:       RoudoStateWorking,
+			lastEventAt: at(2, 11, 0),
+			fix:         true,
+			want: []Finding{
+				{Severity: SeverityError, Message: "current_state が working なのに進行中の労働がありません。労働外にします", Fixable: true, Fixed: true},
+			},
+			wantState: RoudoStateOff,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reporter, repo, audit, _ := newTestReporter(t, DefaultConfig(), now)
+			for date, rs := range tt.stored {
+				if err := repo.SaveRoudoReport(date, rs); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := repo.SaveCurrentState(tt.state); err != nil {
+				t.Fatal(err)
+			}
+			if tt.lastEventAt != nil {
+				if err := repo.SaveLastEventAt(NewRoudoTime(*tt.lastEventAt, 0)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := reporter.Doctor(tt.fix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %+v, want %+v", got, tt.want)
+			}
+
+			for date, stored := range tt.stored {
+				want, repaired := tt.wantReports[date]
+				if !repaired {
+					want = stored
+				}
+				rs, err := repo.GetRoudoReport(date)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if same, _ := sameRoudos(rs, want); !same {
+					t.Errorf("%s: got %+v, want %+v", date, rs, want)
+				}
+
+				// 修復した日だけ doctor による書き換えとして監査ログに残る
+				entries, err := audit.List(date)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !repaired {
+					if len(entries) != 0 {
+						t.Errorf("%s: audit entries = %+v, want none", date, entries)
+					}
+					continue
+				}
+				if len(entries) != 1 || entries[0].Source != AuditSourceDoctor {
+					t.Fatalf("%s: audit entries = %+v, want 1 entry from %s", date, entries, AuditSourceDoctor)
+				}
+				before, err := entries[0].BeforeRoudos()
+				if err != nil {
+					t.Fatal(err)
+				}
+				after, err := entries[0].AfterRoudos()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if same, _ := sameRoudos(before, stored); !same {
+					t.Errorf("%s: audit before = %+v, want %+v", date, before, stored)
+				}
+				if same, _ := sameRoudos(after, want); !same {
+					t.Errorf("%s: audit after = %+v, want %+v", date, after, want)
+				}
+			}
+
+			state, err := repo.GetCurrentState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state != tt.wantState {
+				t.Errorf("current_state = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestDiagnoseState(t *testing.T) {
+	at := func(hour, min int) *time.Time {
+		t := time.Date(2024, 3, 1, hour, min, 0, 0, time.Local)
+		return &t
+	}
+	tests := []struct {
+		name        string
+		state       RoudoState
+		lastEventAt *time.Time
+		current     []Roudo
+		want        []Finding
+		wantState   RoudoState
+	}{
+		{
+			name:      "労働外なら調べない",
+			state:     RoudoStateOff,
+			wantState: RoudoStateOff,
+		},
+		{
+			name:        "進行中の労働と一致している",
+			state:       RoudoStateWorking,
+			lastEventAt: at(11, 0),
+			current:     []Roudo{{StartAt: at(9, 0)}},
+			wantState:   RoudoStateWorking,
+		},
+		{
+			name:        "進行中の休憩と一致している",
+			state:       RoudoStateBreaking,
+			lastEventAt: at(11, 0),
+			current:     []Roudo{{StartAt: at(9, 0), Breaks: []Break{{StartAt: *at(11, 0)}}}},
+			wantState:   RoudoStateBreaking,
+		},
+		{
+			name:    "最終イベント時刻がない",
+			state:   RoudoStateWorking,
+			current: []Roudo{{StartAt: at(9, 0)}},
+			want: []Finding{
+				{Severity: SeverityError, Message: "current_state が working なのに最終イベント時刻がありません。労働外にします", Fixable: true},
+			},
+			wantState: RoudoStateOff,
+		},
+		{
+			name:        "最後の労働が終了している",
+			state:       RoudoStateBreaking,
+			lastEventAt: at(11, 0),
+			current:     []Roudo{{StartAt: at(9, 0), EndAt: at(10, 0)}},
+			want: []Finding{
+				{Severity: SeverityError, Message: "current_state が breaking なのに進行中の労働がありません。労働外にします", Fixable: true},
+			},
+			wantState: RoudoStateOff,
+		},
+		{
+			name:        "working なのに休憩が終了していない",
+			state:       RoudoStateWorking,
+			lastEventAt: at(11, 0),
+			current:     []Roudo{{StartAt: at(9, 0), Breaks: []Break{{StartAt: *at(10, 0)}}}},
+			want: []Finding{
+				{Severity: SeverityWarning, Message: "current_state が working なのに休憩が終了していません。休憩中にします", Fixable: true},
+			},
+			wantState: RoudoStateBreaking,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, state := diagnoseState(tt.state, tt.lastEventAt, tt.current)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %+v, want %+v", got, tt.want)
+			}
+			if state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
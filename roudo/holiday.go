@@ -0,0 +1,240 @@
+package roudo
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// HolidayCalendar は日本の祝日と会社独自の休日を扱う
+type HolidayCalendar struct {
+	company map[Date]string
+}
+
+func NewHolidayCalendar(company map[Date]string) *HolidayCalendar {
+	return &HolidayCalendar{company: company}
+}
+
+// LoadCompanyHolidays は1行に「YYYY-MM-DD 名前」の形式で書かれた休日ファイルを読み込む。# 以降はコメントとして無視する
+func LoadCompanyHolidays(path string) (map[Date]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	holidays := make(map[Date]string)
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text, _, _ := strings.Cut(sc.Text(), "#")
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		date, name, _ := strings.Cut(text, " ")
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("%s:%d: 日付の形式が不正です: %s", path, line, date)
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			name = "会社休日"
+		}
+		holidays[Date(date)] = name
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// HolidayName は t が祝日か会社休日であればその名前を返す
+func (c *HolidayCalendar) HolidayName(t time.Time) (string, bool) {
+	if name, ok := JapaneseHolidayName(t); ok {
+		return name, true
+	}
+	if c == nil {
+		return "", false
+	}
+	name, ok := c.company[Date(t.Format("2006-01-02"))]
+	return name, ok
+}
+
+func (c *HolidayCalendar) IsHoliday(t time.Time) bool {
+	_, ok := c.HolidayName(t)
+	return ok
+}
+
+// IsLegalHoliday は週1日の法定休日として日曜日を返す。祝日・会社休日は労働日ではないが法定休日ではないので、
+// その日の労働は休日労働ではなく1日8時間・週40時間を超えた分が時間外労働になる
+func (c *HolidayCalendar) IsLegalHoliday(t time.Time) bool {
+	return IsSunday(t)
+}
+
+// IsWorkingDay は土日と祝日・会社休日を除いた所定労働日かを返す
+func (c *HolidayCalendar) IsWorkingDay(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.IsHoliday(t)
+}
+
+// JapaneseHolidayName は「国民の祝日に関する法律」に基づく祝日、振替休日、国民の休日を判定する。2000年以降に対応
+func JapaneseHolidayName(t time.Time) (string, bool) {
+	y, m, d := t.Date()
+	if name, ok := nationalHoliday(y, m, d); ok {
+		return name, true
+	}
+
+	// 振替休日: 日曜日の祝日以降で最初の祝日でない日
+	if y >= 2007 || t.Weekday() == time.Monday {
+		for prev := t.AddDate(0, 0, -1); ; prev = prev.AddDate(0, 0, -1) {
+			if _, ok := nationalHoliday(prev.Date()); !ok {
+				break
+			}
+			if prev.Weekday() == time.Sunday {
+				return "振替休日", true
+			}
+			if y < 2007 {
+				break
+			}
+		}
+	}
+
+	// 国民の休日: 前日と翌日が祝日である日
+	if t.Weekday() != time.Sunday {
+		_, prevOk := nationalHoliday(t.AddDate(0, 0, -1).Date())
+		_, nextOk := nationalHoliday(t.AddDate(0, 0, 1).Date())
+		if prevOk && nextOk {
+			return "国民の休日", true
+		}
+	}
+	return "", false
+}
+
+// nationalHoliday は振替休日と国民の休日を除く祝日を判定する
+func nationalHoliday(y int, m time.Month, d int) (string, bool) {
+	switch m {
+	case time.January:
+		if d == 1 {
+			return "元日", true
+		}
+		if d == nthMonday(y, m, 2) {
+			return "成人の日", true
+		}
+	case time.February:
+		if d == 11 {
+			return "建国記念の日", true
+		}
+		if d == 23 && y >= 2020 {
+			return "天皇誕生日", true
+		}
+	case time.March:
+		if d == vernalEquinoxDay(y) {
+			return "春分の日", true
+		}
+	case time.April:
+		if d == 29 {
+			if y >= 2007 {
+				return "昭和の日", true
+			}
+			return "みどりの日", true
+		}
+		if y == 2019 && d == 30 {
+			return "国民の休日", true
+		}
+	case time.May:
+		switch {
+		case y == 2019 && d == 1:
+			return "天皇の即位の日", true
+		case y == 2019 && d == 2:
+			return "国民の休日", true
+		case d == 3:
+			return "憲法記念日", true
+		case d == 4 && y >= 2007:
+			return "みどりの日", true
+		case d == 5:
+			return "こどもの日", true
+		}
+	case time.July:
+		switch y {
+		case 2020:
+			if d == 23 {
+				return "海の日", true
+			}
+			if d == 24 {
+				return "スポーツの日", true
+			}
+		case 2021:
+			if d == 22 {
+				return "海の日", true
+			}
+			if d == 23 {
+				return "スポーツの日", true
+			}
+		default:
+			if (y >= 2003 && d == nthMonday(y, m, 3)) || (y < 2003 && d == 20) {
+				return "海の日", true
+			}
+		}
+	case time.August:
+		switch {
+		case y == 2020:
+			if d == 10 {
+				return "山の日", true
+			}
+		case y == 2021:
+			if d == 8 {
+				return "山の日", true
+			}
+		case y >= 2016 && d == 11:
+			return "山の日", true
+		}
+	case time.September:
+		if (y >= 2003 && d == nthMonday(y, m, 3)) || (y < 2003 && d == 15) {
+			return "敬老の日", true
+		}
+		if d == autumnalEquinoxDay(y) {
+			return "秋分の日", true
+		}
+	case time.October:
+		if y == 2019 && d == 22 {
+			return "即位礼正殿の儀の行われる日", true
+		}
+		if y != 2020 && y != 2021 && d == nthMonday(y, m, 2) {
+			if y >= 2020 {
+				return "スポーツの日", true
+			}
+			return "体育の日", true
+		}
+	case time.November:
+		if d == 3 {
+			return "文化の日", true
+		}
+		if d == 23 {
+			return "勤労感謝の日", true
+		}
+	case time.December:
+		if d == 23 && y <= 2018 {
+			return "天皇誕生日", true
+		}
+	}
+	return "", false
+}
+
+// nthMonday は y 年 m 月の第 n 月曜日の日を返す
+func nthMonday(y int, m time.Month, n int) int {
+	first := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(time.Monday) - int(first.Weekday()) + 7) % 7
+	return 1 + offset + (n-1)*7
+}
+
+// vernalEquinoxDay と autumnalEquinoxDay は 1980~2099 年で有効な近似式で春分日・秋分日を求める
+func vernalEquinoxDay(y int) int {
+	return int(20.8431+0.242194*float64(y-1980)) - (y-1980)/4
+}
+
+func autumnalEquinoxDay(y int) int {
+	return int(23.2488+0.242194*float64(y-1980)) - (y-1980)/4
+}
@@ -0,0 +1,302 @@
+package roudo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+type ImportFormat string
+
+const (
+	ImportFormatCSV  = ImportFormat("csv")
+	ImportFormatJSON = ImportFormat("json")
+)
+
+// Importer は export コマンドと同じ形式の勤怠データを日付ごとの []Roudo に変換する。
+// タグと作業内容は JSON にしか出力されないので、CSV からは取り込まない
+type Importer struct {
+	shiftDuration time.Duration
+	loc           *time.Location
+}
+
+func NewImporter(shiftDuration time.Duration, loc *time.Location) *Importer {
+	return &Importer{shiftDuration: shiftDuration, loc: loc}
+}
+
+func (im *Importer) Parse(r io.Reader, format ImportFormat) (map[Date][]Roudo, error) {
+	var (
+		reports map[Date][]Roudo
+		err     error
+	)
+	switch format {
+	case ImportFormatCSV:
+		reports, err = im.parseCSV(r)
+	case ImportFormatJSON:
+		reports, err = im.parseJSON(r)
+	default:
+		return nil, fmt.Errorf("未対応の入力形式です: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rs := range reports {
+		sortReport(rs)
+	}
+	return reports, nil
+}
+
+func (im *Importer) parseCSV(r io.Reader) (map[Date][]Roudo, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("ヘッダ行が読み込めません: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		columns[h] = i
+	}
+	for _, required := range []string{"date", "start_at", "end_at"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%s 列がありません", required)
+		}
+	}
+	get := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	reports := make(map[Date][]Roudo)
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		date := Date(get(record, "date"))
+		if get(record, "start_at") == "" {
+			// 労働のない日も export では1行出力される
+			if _, err := date.Time(); err != nil {
+				return nil, fmt.Errorf("%d 行目: 日付の形式が不正です: %s", line, date)
+			}
+			continue
+		}
+
+		session, err := im.parseSession(date, get(record, "start_at"), get(record, "end_at"))
+		if err != nil {
+			return nil, fmt.Errorf("%d 行目: %w", line, err)
+		}
+
+		// 同じ労働に複数の休憩がある場合は、休憩ごとに同じ労働の行が続く
+		rs := reports[date]
+		if len(rs) == 0 || !rs[len(rs)-1].StartAt.Equal(*session.StartAt) {
+			rs = append(rs, session)
+		}
+		if get(record, "break_start_at") != "" {
+			b, err := im.parseBreak(date, get(record, "break_start_at"), get(record, "break_end_at"))
+			if err != nil {
+				return nil, fmt.Errorf("%d 行目: %w", line, err)
+			}
+			rs[len(rs)-1].Breaks = append(rs[len(rs)-1].Breaks, b)
+		}
+		reports[date] = rs
+	}
+	return reports, nil
+}
+
+type importBreak struct {
+	StartAt string  `json:"start_at"`
+	EndAt   *string `json:"end_at"`
+}
+
+type importTag struct {
+	Project string  `json:"project"`
+	Task    string  `json:"task"`
+	StartAt string  `json:"start_at"`
+	EndAt   *string `json:"end_at"`
+}
+
+type importActivity struct {
+	Label   string  `json:"label"`
+	StartAt string  `json:"start_at"`
+	EndAt   *string `json:"end_at"`
+}
+
+type importSession struct {
+	StartAt    string           `json:"start_at"`
+	EndAt      *string          `json:"end_at"`
+	Breaks     []importBreak    `json:"breaks"`
+	Tags       []importTag      `json:"tags"`
+	Activities []importActivity `json:"activities"`
+}
+
+type importDay struct {
+	Date     Date            `json:"date"`
+	Sessions []importSession `json:"sessions"`
+}
+
+func (im *Importer) parseJSON(r io.Reader) (map[Date][]Roudo, error) {
+	var m struct {
+		Days []importDay `json:"days"`
+	}
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	reports := make(map[Date][]Roudo)
+	for _, d := range m.Days {
+		if _, err := d.Date.Time(); err != nil {
+			return nil, fmt.Errorf("日付の形式が不正です: %s", d.Date)
+		}
+		if len(d.Sessions) == 0 {
+			continue
+		}
+		for _, s := range d.Sessions {
+			session, err := im.parseSession(d.Date, s.StartAt, deref(s.EndAt))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", d.Date, err)
+			}
+			for _, b := range s.Breaks {
+				br, err := im.parseBreak(d.Date, b.StartAt, deref(b.EndAt))
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", d.Date, err)
+				}
+				session.Breaks = append(session.Breaks, br)
+			}
+			for _, tag := range s.Tags {
+				t, err := im.parseTag(d.Date, tag)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", d.Date, err)
+				}
+				session.Tags = append(session.Tags, t)
+			}
+			for _, a := range s.Activities {
+				activity, err := im.parseActivity(d.Date, a)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", d.Date, err)
+				}
+				session.Activities = append(session.Activities, activity)
+			}
+			reports[d.Date] = append(reports[d.Date], session)
+		}
+	}
+	return reports, nil
+}
+
+func (im *Importer) parseSession(date Date, startAt, endAt string) (Roudo, error) {
+	s, err := im.parseTime(date, startAt)
+	if err != nil {
+		return Roudo{}, fmt.Errorf("労働開始時刻が不正です: %w", err)
+	}
+	if s == nil {
+		return Roudo{}, fmt.Errorf("労働開始時刻がありません")
+	}
+	e, err := im.parseTime(date, endAt)
+	if err != nil {
+		return Roudo{}, fmt.Errorf("労働終了時刻が不正です: %w", err)
+	}
+	return Roudo{StartAt: s, EndAt: e}, nil
+}
+
+func (im *Importer) parseBreak(date Date, startAt, endAt string) (Break, error) {
+	s, err := im.parseTime(date, startAt)
+	if err != nil {
+		return Break{}, fmt.Errorf("休憩開始時刻が不正です: %w", err)
+	}
+	if s == nil {
+		return Break{}, fmt.Errorf("休憩開始時刻がありません")
+	}
+	e, err := im.parseTime(date, endAt)
+	if err != nil {
+		return Break{}, fmt.Errorf("休憩終了時刻が不正です: %w", err)
+	}
+	return Break{StartAt: *s, EndAt: e}, nil
+}
+
+func (im *Importer) parseTag(date Date, tag importTag) (Tag, error) {
+	if tag.Project == "" {
+		return Tag{}, fmt.Errorf("タグのプロジェクトがありません")
+	}
+	s, err := im.parseTime(date, tag.StartAt)
+	if err != nil {
+		return Tag{}, fmt.Errorf("タグ %s の開始時刻が不正です: %w", tag.Project, err)
+	}
+	if s == nil {
+		return Tag{}, fmt.Errorf("タグ %s の開始時刻がありません", tag.Project)
+	}
+	e, err := im.parseTime(date, deref(tag.EndAt))
+	if err != nil {
+		return Tag{}, fmt.Errorf("タグ %s の終了時刻が不正です: %w", tag.Project, err)
+	}
+	return Tag{Project: tag.Project, Task: tag.Task, StartAt: *s, EndAt: e}, nil
+}
+
+func (im *Importer) parseActivity(date Date, a importActivity) (Activity, error) {
+	if a.Label == "" {
+		return Activity{}, fmt.Errorf("作業内容のラベルがありません")
+	}
+	s, err := im.parseTime(date, a.StartAt)
+	if err != nil {
+		return Activity{}, fmt.Errorf("作業内容 %s の開始時刻が不正です: %w", a.Label, err)
+	}
+	if s == nil {
+		return Activity{}, fmt.Errorf("作業内容 %s の開始時刻がありません", a.Label)
+	}
+	e, err := im.parseTime(date, deref(a.EndAt))
+	if err != nil {
+		return Activity{}, fmt.Errorf("作業内容 %s の終了時刻が不正です: %w", a.Label, err)
+	}
+	return Activity{Label: a.Label, StartAt: *s, EndAt: e}, nil
+}
+
+// parseTime は RFC3339 か HH:mm を受け付ける。HH:mm の場合は date の日付とし、
+// shiftDuration より前の時刻は日跨ぎ後の翌日の時刻とみなす
+func (im *Importer) parseTime(date Date, s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return &t, nil
+	}
+
+	d, err := date.Time()
+	if err != nil {
+		return nil, err
+	}
+	hm, err := time.Parse("15:04", s)
+	if err != nil {
+		return nil, fmt.Errorf("時刻の形式が不正です: %s", s)
+	}
+	t := time.Date(d.Year(), d.Month(), d.Day(), hm.Hour(), hm.Minute(), 0, 0, im.loc)
+	if time.Duration(hm.Hour())*time.Hour+time.Duration(hm.Minute())*time.Minute < im.shiftDuration {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// sortReport は労働と、その中の休憩を開始時刻順に並べる。不整合の検証は取り込む側で Validator に任せる
+func sortReport(rs []Roudo) {
+	slices.SortFunc(rs, func(a, b Roudo) int { return a.StartAt.Compare(*b.StartAt) })
+	for _, r := range rs {
+		slices.SortFunc(r.Breaks, func(a, b Break) int { return a.StartAt.Compare(b.StartAt) })
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
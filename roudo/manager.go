@@ -1,9 +1,12 @@
 package roudo
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"roudo/roudo_event"
+	"sync"
 	"time"
 )
 
@@ -11,43 +14,173 @@ type RoudoManager struct {
 	reporter        RoudoReporter
 	eventWatchers   []roudo_event.Watcher
 	logger          *slog.Logger
+	clock           Clock
 	exitCh          chan error
 	pollingInterval time.Duration
+	// 労働中はこの間隔の中で届いた入力イベントをまとめ、最後のものだけを保存する
+	batchInterval time.Duration
+	onExit        ExitAction
+
+	mu sync.Mutex
+	// 最後に reporter から返された状態。分からなければ空
+	state     RoudoState
+	pending   *roudo_event.Event
+	lastFlush time.Time
 }
 
-func NewRoudoManager(reporter RoudoReporter, eventWatchers []roudo_event.Watcher, logger *slog.Logger, pollingInterval time.Duration) *RoudoManager {
+func NewRoudoManager(reporter RoudoReporter, eventWatchers []roudo_event.Watcher, logger *slog.Logger, clock Clock, cfg Config) *RoudoManager {
 	return &RoudoManager{
 		reporter:        reporter,
 		eventWatchers:   eventWatchers,
 		logger:          logger,
-		exitCh:          make(chan error),
-		pollingInterval: pollingInterval,
+		clock:           clock,
+		exitCh:          make(chan error, len(eventWatchers)),
+		pollingInterval: cfg.PollingInterval,
+		batchInterval:   cfg.EventBatchInterval,
+		onExit:          cfg.OnExit,
 	}
 }
 
-func (m *RoudoManager) Kansi() error {
+// Kansi は ctx がキャンセルされるまで監視を続ける。キャンセルされた場合は Watcher を止め、溜まったイベントを保存してから nil を返す
+func (m *RoudoManager) Kansi(ctx context.Context) error {
+	var wg sync.WaitGroup
 	for _, watcher := range m.eventWatchers {
 		watcher := watcher
+		wg.Add(1)
 		go func() {
-			m.logger.Debug("start watching: ", watcher.Name())
-			if err := watcher.Watch(func() {
-				if err := m.reporter.HandleRoudoEvent(); err != nil {
-					m.logger.Error("handle event. event: %s, err: %s\n", watcher.Name(), err)
-				}
-			}); err != nil {
+			defer wg.Done()
+			m.logger.Debug("start watching", slog.String("watcher", watcher.Name()))
+			if err := watcher.Watch(m.handleEvent); err != nil {
 				m.exitCh <- fmt.Errorf("failed to start watch. event: %s, err: %s\n", watcher.Name(), err)
 			}
 		}()
 	}
+
+	err := m.loop(ctx)
+	if shutdownErr := m.shutdown(&wg); shutdownErr != nil {
+		err = errors.Join(err, shutdownErr)
+	}
+	return err
+}
+
+func (m *RoudoManager) loop(ctx context.Context) error {
 	m.logger.Debug("start polling")
 	for {
 		select {
-		case <-time.After(m.pollingInterval):
-			if err := m.reporter.Kansi(); err != nil {
+		case <-m.clock.After(m.pollingInterval):
+			if err := m.poll(); err != nil {
 				return err
 			}
 		case err := <-m.exitCh:
 			return err
+		case <-ctx.Done():
+			m.logger.Info("stop kansi", slog.String("reason", context.Cause(ctx).Error()))
+			return nil
+		}
+	}
+}
+
+// shutdown は全ての Watcher を止めて、溜まっている入力イベントを保存し、onExit に応じて労働を区切る
+func (m *RoudoManager) shutdown(wg *sync.WaitGroup) error {
+	var errs []error
+	for _, watcher := range m.eventWatchers {
+		if err := watcher.Stop(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop watcher. event: %s, err: %w", watcher.Name(), err))
+		}
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pending != nil {
+		m.flush()
+	}
+
+	if err := m.exit(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// exit は onExit に応じて、進行中の労働を最終イベント時刻で休憩または終了にする
+func (m *RoudoManager) exit() error {
+	if m.onExit == ExitActionKeep {
+		return nil
+	}
+	return m.reporter.Exit(m.onExit)
+}
+
+// poll は溜まった入力イベントを batchInterval ごとに保存してから reporter.Kansi を呼ぶ。
+// 他のプロセスから手動で打刻された場合も、ここで状態を取り直す
+func (m *RoudoManager) poll() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.pending != nil && m.clock.Now().Sub(m.lastFlush) >= m.batchInterval {
+		m.flush()
+	}
+	s, err := m.reporter.Kansi()
+	if err != nil {
+		m.state = ""
+		return err
+	}
+	m.state = s
+	return nil
+}
+
+// handleEvent は画面ロック・スリープのイベントを入力イベントと分けて reporter に渡す。
+// ウィンドウタイトルは操作がなくても変わるので、ウィンドウの切り替えは入力イベントとして扱わず、作業内容の記録にだけ使う
+func (m *RoudoManager) handleEvent(e roudo_event.Event) {
+	if e.At.IsZero() {
+		e.At = m.clock.Now()
+	}
+	logger := m.logger.With(slog.String("source", e.Source), slog.String("kind", string(e.Kind)))
+
+	if e.Kind.IsSession() {
+		m.mu.Lock()
+		m.state = ""
+		m.mu.Unlock()
+		if err := m.reporter.HandleSessionEvent(e.Kind, e.At); err != nil {
+			logger.Error("handle session event", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	if e.Kind == roudo_event.KindContext {
+		if err := m.reporter.HandleActivityContext(e.ActivityContext()); err != nil {
+			logger.Error("handle activity context", slog.String("error", err.Error()))
+		}
+		return
+	}
+	m.handleRoudoEvent(e)
+}
+
+// handleRoudoEvent は労働中であれば入力イベントを溜めておき、状態が変わりうる場合はすぐに reporter に渡す
+func (m *RoudoManager) handleRoudoEvent(e roudo_event.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state == RoudoStateWorking && m.clock.Now().Sub(m.lastFlush) < m.batchInterval {
+		if m.pending == nil || !e.At.Before(m.pending.At) {
+			m.pending = &e
 		}
+		return
+	}
+	m.pending = &e
+	m.flush()
+}
+
+// flush は溜まっている最後の入力イベントを reporter に渡す。m.mu を取った状態で呼ぶ
+func (m *RoudoManager) flush() {
+	e := *m.pending
+	m.pending = nil
+	m.lastFlush = m.clock.Now()
+
+	s, err := m.reporter.HandleRoudoEvent(e)
+	if err != nil {
+		m.logger.Error("handle event", slog.String("source", e.Source), slog.String("kind", string(e.Kind)), slog.String("error", err.Error()))
+		m.state = ""
+		return
 	}
+	m.state = s
 }
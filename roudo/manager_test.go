@@ -0,0 +1,136 @@
+package roudo
+
+import (
+	"io"
+	"log/slog"
+	"roudo/roudo_event"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubClock は Set するまで時刻が止まっている Clock。After は使わない
+type stubClock struct {
+	now time.Time
+}
+
+func (c *stubClock) Now() time.Time {
+	return c.now
+}
+
+func (c *stubClock) After(d time.Duration) <-chan time.Time {
+	return make(chan time.Time)
+}
+
+func newTestReporter(t *testing.T, cfg Config, now time.Time) (RoudoReporter, RoudoReportRepository, AuditLog, *stubClock) {
+	t.Helper()
+	repo := NewMemoryRoudoReportRepository()
+	audit := NewMemoryAuditLog()
+	clock := &stubClock{now: now}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewRoudoReporter(repo, audit, logger, &NopNotificator{}, NewProcessMutex(), clock, cfg), repo, audit, clock
+}
+
+func newTestManager(t *testing.T, cfg Config, now time.Time) (*RoudoManager, RoudoReportRepository, AuditLog, *stubClock) {
+	t.Helper()
+	reporter, repo, audit, clock := newTestReporter(t, cfg, now)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewRoudoManager(reporter, nil, logger, clock, cfg), repo, audit, clock
+}
+
+func TestRoudoManagerContextEventIsNotInput(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	m, repo, _, clock := newTestManager(t, DefaultConfig(), start)
+
+	m.handleEvent(roudo_event.Event{Source: "test", Kind: roudo_event.KindKey, At: start})
+	// 時計やターミナルのタイトルが変わり続けても、入力がなければ休憩にする
+	for at := start.Add(time.Minute); at.Before(start.Add(40 * time.Minute)); at = at.Add(10 * time.Second) {
+		clock.now = at
+		m.handleEvent(roudo_event.NewContextEvent("test", at, roudo_event.ActivityContext{WindowTitle: at.Format("15:04:05")}))
+	}
+	clock.now = start.Add(40 * time.Minute)
+	if err := m.poll(); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := repo.GetCurrentState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != RoudoStateBreaking {
+		t.Errorf("current_state = %s, want %s", state, RoudoStateBreaking)
+	}
+	lastEventAt, err := repo.GetLastEventAt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventAt == nil || !lastEventAt.Time().Equal(start) {
+		t.Errorf("last_event_at = %v, want %s", lastEventAt, start)
+	}
+	rs, err := repo.GetRoudoReport("2024-03-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 1 || len(rs[0].Breaks) != 1 || !rs[0].Breaks[0].StartAt.Equal(start) {
+		t.Errorf("roudos = %+v, want a break from %s", rs, start)
+	}
+}
+
+func TestRoudoManagerExit(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	lastInput := start.Add(2 * time.Hour)
+	tests := []struct {
+		action     ExitAction
+		wantState  RoudoState
+		wantEndAt  *time.Time
+		wantBreaks int
+	}{
+		{action: ExitActionKeep, wantState: RoudoStateWorking},
+		{action: ExitActionBreak, wantState: RoudoStateBreaking, wantBreaks: 1},
+		{action: ExitActionFinish, wantState: RoudoStateOff, wantEndAt: &lastInput},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.OnExit = tt.action
+			m, repo, audit, clock := newTestManager(t, cfg, start)
+			m.handleEvent(roudo_event.Event{Source: "test", Kind: roudo_event.KindKey, At: start})
+			clock.now = lastInput
+			m.handleEvent(roudo_event.Event{Source: "test", Kind: roudo_event.KindKey, At: lastInput})
+			clock.now = lastInput.Add(10 * time.Minute)
+
+			if err := m.shutdown(&sync.WaitGroup{}); err != nil {
+				t.Fatal(err)
+			}
+
+			state, err := repo.GetCurrentState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state != tt.wantState {
+				t.Errorf("current_state = %s, want %s", state, tt.wantState)
+			}
+			rs, err := repo.GetRoudoReport("2024-03-01")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rs) != 1 || len(rs[0].Breaks) != tt.wantBreaks {
+				t.Fatalf("roudos = %+v", rs)
+			}
+			if (rs[0].EndAt == nil) != (tt.wantEndAt == nil) || (rs[0].EndAt != nil && !rs[0].EndAt.Equal(*tt.wantEndAt)) {
+				t.Errorf("end_at = %v, want %v", rs[0].EndAt, tt.wantEndAt)
+			}
+
+			// 終了時の切り替えは手動の打刻ではなく自動判定として残す
+			entries, err := audit.List("2024-03-01")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				if e.Source != AuditSourceAuto {
+					t.Errorf("audit source = %s, want %s", e.Source, AuditSourceAuto)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package roudo
+
+func (r *roudoReport) Migrate(src RoudoReportRepository) (int, error) {
+	r.lock(AuditSourceMigrate)
+	defer r.unlock()
+
+	dates, err := src.ListDates()
+	if err != nil {
+		return 0, err
+	}
+	for _, date := range dates {
+		rs, err := src.GetRoudoReport(date)
+		if err != nil {
+			return 0, err
+		}
+		if err := r.saveRoudoReport(date, rs); err != nil {
+			return 0, err
+		}
+	}
+
+	s, err := src.GetCurrentState()
+	if err != nil {
+		return 0, err
+	}
+	if err := r.repo.SaveCurrentState(s); err != nil {
+		return 0, err
+	}
+	lastEventAt, err := src.GetLastEventAt()
+	if err != nil {
+		return 0, err
+	}
+	if lastEventAt != nil {
+		if err := r.repo.SaveLastEventAt(*lastEventAt); err != nil {
+			return 0, err
+		}
+	}
+	return len(dates), nil
+}
@@ -0,0 +1,63 @@
+package roudo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMigrate(t *testing.T) {
+	at := func(day, hour int) *time.Time {
+		t := time.Date(2024, 3, day, hour, 0, 0, 0, time.Local)
+		return &t
+	}
+	src := NewMemoryRoudoReportRepository()
+	reports := map[Date][]Roudo{
+		"2024-03-01": {{StartAt: at(1, 9), EndAt: at(1, 18)}},
+		// 移行では元の不整合もそのまま写す
+		"2024-03-02": {{StartAt: at(2, 9), EndAt: at(2, 18)}, {StartAt: at(2, 17), EndAt: at(2, 19)}},
+		"2024-03-04": {{StartAt: at(4, 9)}},
+	}
+	for date, rs := range reports {
+		if err := src.SaveRoudoReport(date, rs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := src.SaveCurrentState(RoudoStateWorking); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.SaveLastEventAt(NewRoudoTime(*at(4, 10), 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	reporter, dst, audit, _ := newTestReporter(t, DefaultConfig(), *at(4, 11))
+	n, err := reporter.Migrate(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(reports) {
+		t.Errorf("migrated = %d, want %d", n, len(reports))
+	}
+
+	for date, want := range reports {
+		got, err := dst.GetRoudoReport(date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if same, _ := sameRoudos(got, want); !same {
+			t.Errorf("%s = %+v, want %+v", date, got, want)
+		}
+		entries, err := audit.List(date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Source != AuditSourceMigrate {
+			t.Errorf("%s の監査ログ = %+v, want 1 entry from %s", date, entries, AuditSourceMigrate)
+		}
+	}
+	if s, err := dst.GetCurrentState(); err != nil || s != RoudoStateWorking {
+		t.Errorf("state = %s, %v", s, err)
+	}
+	if rt, err := dst.GetLastEventAt(); err != nil || rt == nil || !rt.Time().Equal(*at(4, 10)) {
+		t.Errorf("last_event_at = %v, %v", rt, err)
+	}
+}
@@ -4,15 +4,49 @@ import (
 	"bytes"
 	"fmt"
 	"os/exec"
+	"runtime"
+)
+
+type NotifyEvent string
+
+const (
+	NotifyEventStartWorking   = NotifyEvent("start_working")
+	NotifyEventFinishWorking  = NotifyEvent("finish_working")
+	NotifyEventStartBreaking  = NotifyEvent("start_breaking")
+	NotifyEventFinishBreaking = NotifyEvent("finish_breaking")
 )
 
 type Notificator interface {
-	Notify(title, message string) error
+	Notify(event NotifyEvent, title, message string) error
+}
+
+// NewNotificator は kind に応じた Notificator を返す。kind が auto の場合は runtime.GOOS から選択する
+func NewNotificator(kind string) (Notificator, error) {
+	if kind == "" || kind == "auto" {
+		switch runtime.GOOS {
+		case "darwin":
+			kind = "mac"
+		case "linux":
+			kind = "freedesktop"
+		default:
+			kind = "none"
+		}
+	}
+
+	switch kind {
+	case "mac":
+		return &MacNotificator{}, nil
+	case "freedesktop":
+		return newFreedesktopNotificator()
+	case "none":
+		return &NopNotificator{}, nil
+	}
+	return nil, fmt.Errorf("未対応の notificator です: %s", kind)
 }
 
 type MacNotificator struct{}
 
-func (no *MacNotificator) Notify(title string, message string) error {
+func (no *MacNotificator) Notify(event NotifyEvent, title string, message string) error {
 	var errOut bytes.Buffer
 	cmd := exec.Command("osascript", "-e", `display notification "`+message+`" with title "roudo" subtitle "`+title+`" sound name "Blow"`)
 	cmd.Stderr = &errOut
@@ -21,3 +55,9 @@ func (no *MacNotificator) Notify(title string, message string) error {
 	}
 	return nil
 }
+
+type NopNotificator struct{}
+
+func (no *NopNotificator) Notify(event NotifyEvent, title string, message string) error {
+	return nil
+}
@@ -0,0 +1,113 @@
+package roudo
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	freedesktopNotificationsName = "org.freedesktop.Notifications"
+	freedesktopNotificationsPath = dbus.ObjectPath("/org/freedesktop/Notifications")
+)
+
+type urgency byte
+
+const (
+	urgencyLow      = urgency(0)
+	urgencyNormal   = urgency(1)
+	urgencyCritical = urgency(2)
+)
+
+func (u urgency) String() string {
+	switch u {
+	case urgencyLow:
+		return "low"
+	case urgencyCritical:
+		return "critical"
+	}
+	return "normal"
+}
+
+type notificationStyle struct {
+	urgency urgency
+	icon    string
+}
+
+var notificationStyles = map[NotifyEvent]notificationStyle{
+	NotifyEventStartWorking:   {urgency: urgencyNormal, icon: "media-playback-start"},
+	NotifyEventFinishWorking:  {urgency: urgencyNormal, icon: "media-playback-stop"},
+	NotifyEventStartBreaking:  {urgency: urgencyLow, icon: "media-playback-pause"},
+	NotifyEventFinishBreaking: {urgency: urgencyLow, icon: "media-playback-start"},
+}
+
+// FreedesktopNotificator は D-Bus の org.freedesktop.Notifications で通知する。
+// セッションバスに接続できない場合は notify-send にフォールバックする
+type FreedesktopNotificator struct {
+	mu sync.Mutex
+	// 直前の通知を置き換えるための ID
+	lastID uint32
+}
+
+func NewFreedesktopNotificator() *FreedesktopNotificator {
+	return &FreedesktopNotificator{}
+}
+
+func newFreedesktopNotificator() (Notificator, error) {
+	return NewFreedesktopNotificator(), nil
+}
+
+func (no *FreedesktopNotificator) Notify(event NotifyEvent, title string, message string) error {
+	style, ok := notificationStyles[event]
+	if !ok {
+		style = notificationStyle{urgency: urgencyNormal}
+	}
+
+	if err := no.notifyDBus(style, title, message); err == nil {
+		return nil
+	}
+	return no.notifySend(style, title, message)
+}
+
+func (no *FreedesktopNotificator) notifyDBus(style notificationStyle, title, message string) error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return err
+	}
+
+	no.mu.Lock()
+	defer no.mu.Unlock()
+
+	hints := map[string]dbus.Variant{
+		"urgency": dbus.MakeVariant(byte(style.urgency)),
+	}
+	var id uint32
+	err = conn.Object(freedesktopNotificationsName, freedesktopNotificationsPath).
+		Call(freedesktopNotificationsName+".Notify", 0,
+			"roudo", no.lastID, style.icon, title, message, []string{}, hints, int32(-1)).
+		Store(&id)
+	if err != nil {
+		return err
+	}
+	no.lastID = id
+	return nil
+}
+
+func (no *FreedesktopNotificator) notifySend(style notificationStyle, title, message string) error {
+	args := []string{"-a", "roudo", "-u", style.urgency.String()}
+	if style.icon != "" {
+		args = append(args, "-i", style.icon)
+	}
+	args = append(args, title, message)
+
+	var errOut bytes.Buffer
+	cmd := exec.Command("notify-send", args...)
+	cmd.Stderr = &errOut
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("notify-send: %s: %w", errOut.String(), err)
+	}
+	return nil
+}
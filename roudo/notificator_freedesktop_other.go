@@ -0,0 +1,10 @@
+//go:build !linux
+
+package roudo
+
+import "errors"
+
+// godbus は BSD に対応していないので、freedesktop の通知は linux でだけ使える
+func newFreedesktopNotificator() (Notificator, error) {
+	return nil, errors.New("freedesktop の通知は linux でのみ使えます")
+}
@@ -0,0 +1,41 @@
+package roudo
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func TestNewNotificator(t *testing.T) {
+	if no, err := NewNotificator("none"); err != nil {
+		t.Fatal(err)
+	} else if _, ok := no.(*NopNotificator); !ok {
+		t.Errorf("none = %T", no)
+	}
+	if _, err := NewNotificator("growl"); err == nil {
+		t.Error("未対応の notificator がエラーになりません")
+	}
+
+	no, err := NewNotificator("auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		if _, ok := no.(*MacNotificator); !ok {
+			t.Errorf("auto = %T, want *MacNotificator", no)
+		}
+	case "linux":
+		// FreedesktopNotificator は linux でしかビルドされないので型名で比べる
+		if got := fmt.Sprintf("%T", no); got != "*roudo.FreedesktopNotificator" {
+			t.Errorf("auto = %s, want *roudo.FreedesktopNotificator", got)
+		}
+	default:
+		if _, ok := no.(*NopNotificator); !ok {
+			t.Errorf("auto = %T, want *NopNotificator", no)
+		}
+		if _, err := NewNotificator("freedesktop"); err == nil {
+			t.Error("linux 以外で freedesktop を選べます")
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package roudo
+
+import "time"
+
+// 労働基準法に基づく時間外労働の基準
+const (
+	legalDailyWorkingTime    = 8 * time.Hour
+	legalWeeklyWorkingTime   = 40 * time.Hour
+	monthlyOvertimeThreshold = 60 * time.Hour
+
+	lateNightStartHour = 22
+	lateNightEndHour   = 5
+)
+
+type Overtime struct {
+	// 実労働時間
+	WorkingTime time.Duration
+	// 1日8時間を超えた時間外労働
+	Daily time.Duration
+	// 週40時間を超えた時間外労働。Daily に含まれる分は除く
+	Weekly time.Duration
+	// 22:00~05:00 の深夜労働
+	LateNight time.Duration
+	// 法定休日の労働。Daily, Weekly には含めない
+	Holiday time.Duration
+	// 月の時間外労働のうち60時間を超えた分。Daily + Weekly の内数
+	Over60 time.Duration
+}
+
+// Total は時間外労働の合計を返す
+func (o Overtime) Total() time.Duration {
+	return o.Daily + o.Weekly
+}
+
+func (o *Overtime) add(other Overtime) {
+	o.WorkingTime += other.WorkingTime
+	o.Daily += other.Daily
+	o.Weekly += other.Weekly
+	o.LateNight += other.LateNight
+	o.Holiday += other.Holiday
+	o.Over60 += other.Over60
+}
+
+type DailyOvertime struct {
+	Date Date
+	Overtime
+}
+
+// IsSunday は日曜日を法定休日とする判定
+func IsSunday(t time.Time) bool {
+	return t.Weekday() == time.Sunday
+}
+
+// WeekStart は t を含む週の最初の日を返す。週40時間の計算も週の表示も、労働基準法の原則どおり日曜始まりの週を使う
+func WeekStart(t time.Time) time.Time {
+	return t.AddDate(0, 0, -int(t.Weekday()))
+}
+
+// CalcOvertime は日付順に並んだ1ヶ月分の reports から日毎の時間外労働と月の合計を計算する。
+// 週は WeekStart で区切る。preceding には reports の最初の週のうち reports より前の日の記録を渡し、週40時間の計算にだけ使う
+func CalcOvertime(reports, preceding []DailyReport, isLegalHoliday func(time.Time) bool) ([]DailyOvertime, Overtime, error) {
+	var (
+		dailies      []DailyOvertime
+		total        Overtime
+		weekStart    time.Time
+		weekWorking  time.Duration
+		monthOverAll time.Duration
+	)
+	calcDay := func(report DailyReport) (Overtime, error) {
+		d, err := report.Date.Time()
+		if err != nil {
+			return Overtime{}, err
+		}
+		if ws := WeekStart(d); !ws.Equal(weekStart) {
+			weekStart = ws
+			weekWorking = 0
+		}
+
+		o := Overtime{}
+		for _, r := range report.Roudos {
+			o.WorkingTime += r.TotalWorkingTime()
+			o.LateNight += r.lateNightWorkingTime()
+		}
+
+		if isLegalHoliday(d) {
+			o.Holiday = o.WorkingTime
+		} else {
+			o.Daily = max(o.WorkingTime-legalDailyWorkingTime, 0)
+			weekWorking += o.WorkingTime - o.Daily
+			if weekWorking > legalWeeklyWorkingTime {
+				o.Weekly = weekWorking - legalWeeklyWorkingTime
+				weekWorking = legalWeeklyWorkingTime
+			}
+		}
+		return o, nil
+	}
+
+	// 前月から続く週の労働時間を数えておく。時間外労働はその月の分として計算済みなので合計には含めない
+	for _, report := range preceding {
+		if _, err := calcDay(report); err != nil {
+			return nil, Overtime{}, err
+		}
+	}
+	for _, report := range reports {
+		o, err := calcDay(report)
+		if err != nil {
+			return nil, Overtime{}, err
+		}
+
+		before := max(monthOverAll-monthlyOvertimeThreshold, 0)
+		monthOverAll += o.Total()
+		o.Over60 = max(monthOverAll-monthlyOvertimeThreshold, 0) - before
+
+		dailies = append(dailies, DailyOvertime{Date: report.Date, Overtime: o})
+		total.add(o)
+	}
+	return dailies, total, nil
+}
+
+// lateNightWorkingTime は休憩を除いた労働のうち 22:00~05:00 に含まれる時間を返す
+func (r *Roudo) lateNightWorkingTime() time.Duration {
+	if r.StartAt == nil || r.EndAt == nil {
+		return 0
+	}
+
+	var total time.Duration
+	for _, iv := range r.workingIntervals() {
+		start := iv[0].In(time.Local)
+		day := time.Date(start.Year(), start.Month(), start.Day()-1, 0, 0, 0, 0, time.Local)
+		for !day.After(iv[1]) {
+			nightStart := day.Add(lateNightStartHour * time.Hour)
+			nightEnd := day.AddDate(0, 0, 1).Add(lateNightEndHour * time.Hour)
+			total += overlap(iv[0], iv[1], nightStart, nightEnd)
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+	return total
+}
+
+// workingIntervals は労働時間から終了済みの休憩を除いた区間を返す
+func (r *Roudo) workingIntervals() [][2]time.Time {
+	cursor := *r.StartAt
+	var intervals [][2]time.Time
+	for _, b := range r.Breaks {
+		if b.EndAt == nil || !b.StartAt.After(cursor) {
+			if b.EndAt != nil && b.EndAt.After(cursor) {
+				cursor = *b.EndAt
+			}
+			continue
+		}
+		end := b.StartAt
+		if end.After(*r.EndAt) {
+			end = *r.EndAt
+		}
+		intervals = append(intervals, [2]time.Time{cursor, end})
+		cursor = *b.EndAt
+	}
+	if r.EndAt.After(cursor) {
+		intervals = append(intervals, [2]time.Time{cursor, *r.EndAt})
+	}
+	return intervals
+}
+
+func overlap(aStart, aEnd, bStart, bEnd time.Time) time.Duration {
+	start := aStart
+	if bStart.After(start) {
+		start = bStart
+	}
+	end := aEnd
+	if bEnd.Before(end) {
+		end = bEnd
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
@@ -0,0 +1,121 @@
+package roudo
+
+import (
+	"testing"
+	"time"
+)
+
+// workedReport は date の start 時から hours 時間休憩なしで働いた記録を返す
+func workedReport(t *testing.T, date Date, start, hours int) DailyReport {
+	t.Helper()
+	d, err := date.Time()
+	if err != nil {
+		t.Fatal(err)
+	}
+	startAt := d.Add(time.Duration(start) * time.Hour)
+	endAt := startAt.Add(time.Duration(hours) * time.Hour)
+	return DailyReport{Date: date, Roudos: []Roudo{{StartAt: &startAt, EndAt: &endAt}}}
+}
+
+func TestCalcOvertimeHolidays(t *testing.T) {
+	calendar := NewHolidayCalendar(map[Date]string{"2024-05-07": "創立記念日"})
+	tests := []struct {
+		name    string
+		date    Date
+		hours   int
+		wantDay Overtime
+	}{
+		{
+			name:    "日曜日は法定休日",
+			date:    "2024-05-05", // こどもの日
+			hours:   9,
+			wantDay: Overtime{WorkingTime: 9 * time.Hour, Holiday: 9 * time.Hour},
+		},
+		{
+			name:    "平日の祝日は8時間を超えた分が時間外労働",
+			date:    "2024-05-06", // 振替休日
+			hours:   9,
+			wantDay: Overtime{WorkingTime: 9 * time.Hour, Daily: time.Hour},
+		},
+		{
+			name:    "会社休日は8時間を超えた分が時間外労働",
+			date:    "2024-05-07",
+			hours:   10,
+			wantDay: Overtime{WorkingTime: 10 * time.Hour, Daily: 2 * time.Hour},
+		},
+		{
+			name:    "土曜日は法定休日ではない",
+			date:    "2024-05-11",
+			hours:   8,
+			wantDay: Overtime{WorkingTime: 8 * time.Hour},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dailies, _, err := CalcOvertime([]DailyReport{workedReport(t, tt.date, 9, tt.hours)}, nil, calendar.IsLegalHoliday)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dailies[0].Overtime; got != tt.wantDay {
+				t.Errorf("overtime = %+v, want %+v", got, tt.wantDay)
+			}
+		})
+	}
+}
+
+func TestCalcOvertimeHolidaysCountTowardWeek(t *testing.T) {
+	calendar := NewHolidayCalendar(nil)
+	// 2024-05-05 (日) からの週。月曜日の振替休日も含めて8時間ずつ6日働く
+	var reports []DailyReport
+	for _, date := range []Date{"2024-05-05", "2024-05-06", "2024-05-07", "2024-05-08", "2024-05-09", "2024-05-10", "2024-05-11"} {
+		reports = append(reports, workedReport(t, date, 9, 8))
+	}
+	dailies, total, err := CalcOvertime(reports, nil, calendar.IsLegalHoliday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 日曜日は休日労働、月~金で40時間に達するので土曜日が時間外労働になる
+	if total.Holiday != 8*time.Hour {
+		t.Errorf("holiday = %s, want 8h", total.Holiday)
+	}
+	if total.Weekly != 8*time.Hour || dailies[6].Weekly != 8*time.Hour {
+		t.Errorf("weekly = %s (土曜日 %s), want 8h", total.Weekly, dailies[6].Weekly)
+	}
+}
+
+func TestCalcOvertimeWeekSpanningMonths(t *testing.T) {
+	calendar := NewHolidayCalendar(nil)
+	// 2024-05-01 (水) の週は 04-28 (日) から始まる。前月の月・火と5月の水~土に8時間ずつ働く
+	preceding := []DailyReport{workedReport(t, "2024-04-28", 9, 0), workedReport(t, "2024-04-29", 9, 8), workedReport(t, "2024-04-30", 9, 8)}
+	var reports []DailyReport
+	for _, date := range []Date{"2024-05-01", "2024-05-02", "2024-05-03", "2024-05-04", "2024-05-05", "2024-05-06"} {
+		reports = append(reports, workedReport(t, date, 9, 8))
+	}
+
+	tests := []struct {
+		name       string
+		preceding  []DailyReport
+		wantWeekly time.Duration
+	}{
+		{name: "前月の労働も週40時間に数える", preceding: preceding, wantWeekly: 8 * time.Hour},
+		{name: "前月の記録がなければ月内だけで数える", preceding: nil, wantWeekly: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dailies, total, err := CalcOvertime(reports, tt.preceding, calendar.IsLegalHoliday)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(dailies) != len(reports) || dailies[0].Date != "2024-05-01" {
+				t.Fatalf("前月の日を結果に含めています: %+v", dailies)
+			}
+			// 土曜日で40時間を超え、日曜日は休日労働、月曜日から新しい週になる
+			if total.Weekly != tt.wantWeekly || dailies[3].Weekly != tt.wantWeekly {
+				t.Errorf("weekly = %s (05-04 %s), want %s", total.Weekly, dailies[3].Weekly, tt.wantWeekly)
+			}
+			if total.WorkingTime != 48*time.Hour {
+				t.Errorf("working = %s, want 48h", total.WorkingTime)
+			}
+		})
+	}
+}
@@ -14,8 +14,18 @@ type RoudoReportRepository interface {
 	GetLastEventAt() (*RoudoTime, error)
 	SaveLastEventAt(rt RoudoTime) error
 
+	// SaveRoudoReport は rs が空であれば date の労働記録を消す。空の記録と記録のない日は区別しない
 	SaveRoudoReport(date Date, rs []Roudo) error
 	GetRoudoReport(date Date) ([]Roudo, error)
+	RoudoReportLister
+	// ListDates は労働記録が保存されている日付を昇順で返す
+	ListDates() ([]Date, error)
+}
+
+// RoudoReportLister は期間の労働記録の読み取り。リポジトリからもデーモン経由でも読める
+type RoudoReportLister interface {
+	// ListRoudoReports は from から to まで (両端を含む) の労働記録を日付ごとに返す。記録のない日は含まない
+	ListRoudoReports(from, to Date) (map[Date][]Roudo, error)
 }
 
 func NewRoudoReportRepository(db *buntdb.DB) RoudoReportRepository {
@@ -88,6 +98,13 @@ func (r *roudoRepository) GetLastEventAt() (*RoudoTime, error) {
 
 func (r *roudoRepository) SaveRoudoReport(date Date, rs []Roudo) error {
 	return r.db.Update(func(tx *buntdb.Tx) error {
+		if len(rs) == 0 {
+			_, err := tx.Delete(string(date))
+			if errors.Is(err, buntdb.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
 		bs, err := json.Marshal(rs)
 		if err != nil {
 			return err
@@ -116,3 +133,49 @@ func (r *roudoRepository) GetRoudoReport(date Date) ([]Roudo, error) {
 	}
 	return rs, nil
 }
+
+func (r *roudoRepository) ListRoudoReports(from, to Date) (map[Date][]Roudo, error) {
+	reports := make(map[Date][]Roudo)
+	err := r.db.View(func(tx *buntdb.Tx) error {
+		var decodeErr error
+		// 日付のキーは YYYY-MM-DD なので、キーの順序がそのまま日付の順序になる
+		err := tx.AscendRange("", string(from), string(to)+"\xff", func(key, value string) bool {
+			if _, err := time.Parse("2006-01-02", key); err != nil {
+				return true
+			}
+			var rs []Roudo
+			if decodeErr = json.Unmarshal([]byte(value), &rs); decodeErr != nil {
+				return false
+			}
+			if len(rs) != 0 {
+				reports[Date(key)] = rs
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		return decodeErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+func (r *roudoRepository) ListDates() ([]Date, error) {
+	var dates []Date
+	err := r.db.View(func(tx *buntdb.Tx) error {
+		return tx.AscendKeys("*", func(key, value string) bool {
+			// 空の記録を消すようになる前に保存された null や [] は、記録のない日として扱う
+			if _, err := time.Parse("2006-01-02", key); err == nil && value != "null" && value != "[]" {
+				dates = append(dates, Date(key))
+			}
+			return true
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dates, nil
+}
@@ -0,0 +1,212 @@
+package roudo
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const jsonStateFile = "state.json"
+
+// NewJSONRoudoReportRepository は dir 以下に月ごとの JSON ファイル (2006-01.json) として労働記録を保存する。
+// 労働記録と頻繁に更新される状態を別ファイルに分け、git や Dropbox で扱いやすくしている
+func NewJSONRoudoReportRepository(dir string) (RoudoReportRepository, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &jsonRoudoRepository{dir: dir}, nil
+}
+
+type jsonRoudoRepository struct {
+	mu  sync.Mutex
+	dir string
+}
+
+type jsonState struct {
+	CurrentState RoudoState `json:"current_state,omitempty"`
+	LastEventAt  *time.Time `json:"last_event_at,omitempty"`
+}
+
+func (r *jsonRoudoRepository) SaveCurrentState(s RoudoState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st, err := r.readState()
+	if err != nil {
+		return err
+	}
+	st.CurrentState = s
+	return r.writeFile(jsonStateFile, st)
+}
+
+func (r *jsonRoudoRepository) GetCurrentState() (RoudoState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st, err := r.readState()
+	if err != nil {
+		return "", err
+	}
+	if st.CurrentState == "" {
+		return RoudoStateOff, nil
+	}
+	return st.CurrentState, nil
+}
+
+func (r *jsonRoudoRepository) SaveLastEventAt(rt RoudoTime) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st, err := r.readState()
+	if err != nil {
+		return err
+	}
+	t := rt.t.Truncate(time.Second)
+	st.LastEventAt = &t
+	return r.writeFile(jsonStateFile, st)
+}
+
+func (r *jsonRoudoRepository) GetLastEventAt() (*RoudoTime, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	st, err := r.readState()
+	if err != nil {
+		return nil, err
+	}
+	if st.LastEventAt == nil {
+		return nil, nil
+	}
+	rt := NewRoudoTime(*st.LastEventAt, 0)
+	return &rt, nil
+}
+
+func (r *jsonRoudoRepository) SaveRoudoReport(date Date, rs []Roudo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	month, err := r.readMonth(date.month())
+	if err != nil {
+		return err
+	}
+	if len(rs) == 0 {
+		delete(month, date)
+	} else {
+		month[date] = rs
+	}
+	return r.writeFile(date.month()+".json", month)
+}
+
+func (r *jsonRoudoRepository) GetRoudoReport(date Date) ([]Roudo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	month, err := r.readMonth(date.month())
+	if err != nil {
+		return nil, err
+	}
+	return month[date], nil
+}
+
+func (r *jsonRoudoRepository) ListRoudoReports(from, to Date) (map[Date][]Roudo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start, err := from.Time()
+	if err != nil {
+		return nil, err
+	}
+	reports := make(map[Date][]Roudo)
+	// 月ごとのファイルを from の月から to の月まで読む
+	for m := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.Local); m.Format("2006-01") <= to.month(); m = m.AddDate(0, 1, 0) {
+		month, err := r.readMonth(m.Format("2006-01"))
+		if err != nil {
+			return nil, err
+		}
+		for date, rs := range month {
+			if from <= date && date <= to {
+				reports[date] = rs
+			}
+		}
+	}
+	return reports, nil
+}
+
+func (r *jsonRoudoRepository) ListDates() ([]Date, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	paths, err := filepath.Glob(filepath.Join(r.dir, "????-??.json"))
+	if err != nil {
+		return nil, err
+	}
+	var dates []Date
+	for _, path := range paths {
+		month, err := r.readMonth(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		for date := range month {
+			dates = append(dates, date)
+		}
+	}
+	slices.Sort(dates)
+	return dates, nil
+}
+
+func (r *jsonRoudoRepository) readState() (jsonState, error) {
+	var st jsonState
+	err := r.readFile(jsonStateFile, &st)
+	return st, err
+}
+
+func (r *jsonRoudoRepository) readMonth(month string) (map[Date][]Roudo, error) {
+	m := make(map[Date][]Roudo)
+	if err := r.readFile(month+".json", &m); err != nil {
+		return nil, err
+	}
+	// 空の記録を消すようになる前に保存された null や [] は、記録のない日として扱う
+	for date, rs := range m {
+		if len(rs) == 0 {
+			delete(m, date)
+		}
+	}
+	return m, nil
+}
+
+// readFile はファイルが存在しない場合は v を変更せずに nil を返す
+func (r *jsonRoudoRepository) readFile(name string, v any) error {
+	bs, err := os.ReadFile(filepath.Join(r.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(bs, v)
+}
+
+// writeFile は書き込み途中のファイルが残らないよう、一時ファイルに書いてから置き換える
+func (r *jsonRoudoRepository) writeFile(name string, v any) error {
+	bs, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(r.dir, name+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(append(bs, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(r.dir, name))
+}
@@ -0,0 +1,136 @@
+package roudo
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// NewMemoryRoudoReportRepository はメモリ上にのみ保存する RoudoReportRepository を返す。テストや dry-run で使う
+func NewMemoryRoudoReportRepository() RoudoReportRepository {
+	return &memoryRoudoRepository{
+		state:   RoudoStateOff,
+		reports: make(map[Date][]Roudo),
+	}
+}
+
+type memoryRoudoRepository struct {
+	mu          sync.RWMutex
+	state       RoudoState
+	lastEventAt *RoudoTime
+	reports     map[Date][]Roudo
+}
+
+func (r *memoryRoudoRepository) SaveCurrentState(s RoudoState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = s
+	return nil
+}
+
+func (r *memoryRoudoRepository) GetCurrentState() (RoudoState, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.state, nil
+}
+
+func (r *memoryRoudoRepository) SaveLastEventAt(rt RoudoTime) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := NewRoudoTime(*rt.t, 0)
+	r.lastEventAt = &t
+	return nil
+}
+
+func (r *memoryRoudoRepository) GetLastEventAt() (*RoudoTime, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.lastEventAt == nil {
+		return nil, nil
+	}
+	t := *r.lastEventAt
+	return &t, nil
+}
+
+func (r *memoryRoudoRepository) SaveRoudoReport(date Date, rs []Roudo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(rs) == 0 {
+		delete(r.reports, date)
+		return nil
+	}
+	r.reports[date] = CloneRoudos(rs)
+	return nil
+}
+
+func (r *memoryRoudoRepository) GetRoudoReport(date Date) ([]Roudo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return CloneRoudos(r.reports[date]), nil
+}
+
+func (r *memoryRoudoRepository) ListRoudoReports(from, to Date) (map[Date][]Roudo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reports := make(map[Date][]Roudo)
+	for date, rs := range r.reports {
+		if from <= date && date <= to {
+			reports[date] = CloneRoudos(rs)
+		}
+	}
+	return reports, nil
+}
+
+func (r *memoryRoudoRepository) ListDates() ([]Date, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	dates := make([]Date, 0, len(r.reports))
+	for date := range r.reports {
+		dates = append(dates, date)
+	}
+	slices.Sort(dates)
+	return dates, nil
+}
+
+// CloneRoudos は呼び出し元での変更が元のデータに影響しないよう、ポインタも含めて複製する
+func CloneRoudos(rs []Roudo) []Roudo {
+	if rs == nil {
+		return nil
+	}
+	cloned := make([]Roudo, len(rs))
+	for i, r := range rs {
+		cloned[i] = Roudo{
+			StartAt: cloneTimePtr(r.StartAt),
+			EndAt:   cloneTimePtr(r.EndAt),
+		}
+		if r.Breaks != nil {
+			cloned[i].Breaks = make([]Break, len(r.Breaks))
+			for j, b := range r.Breaks {
+				cloned[i].Breaks[j] = Break{StartAt: b.StartAt, EndAt: cloneTimePtr(b.EndAt)}
+			}
+		}
+		if r.Tags != nil {
+			cloned[i].Tags = make([]Tag, len(r.Tags))
+			for j, tag := range r.Tags {
+				tag.EndAt = cloneTimePtr(tag.EndAt)
+				cloned[i].Tags[j] = tag
+			}
+		}
+		if r.Activities != nil {
+			cloned[i].Activities = make([]Activity, len(r.Activities))
+			for j, a := range r.Activities {
+				a.EndAt = cloneTimePtr(a.EndAt)
+				cloned[i].Activities[j] = a
+			}
+		}
+	}
+	return cloned
+}
+
+func cloneTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
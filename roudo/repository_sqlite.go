@@ -0,0 +1,352 @@
+package roudo
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS states (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS sessions (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	date     TEXT NOT NULL,
+	seq      INTEGER NOT NULL,
+	start_at TEXT,
+	end_at   TEXT
+);
+CREATE INDEX IF NOT EXISTS sessions_date_seq ON sessions (date, seq);
+CREATE TABLE IF NOT EXISTS breaks (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id INTEGER NOT NULL REFERENCES sessions (id),
+	seq        INTEGER NOT NULL,
+	start_at   TEXT NOT NULL,
+	end_at     TEXT
+);
+CREATE INDEX IF NOT EXISTS breaks_session_id_seq ON breaks (session_id, seq);
+CREATE TABLE IF NOT EXISTS tags (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id INTEGER NOT NULL REFERENCES sessions (id),
+	seq        INTEGER NOT NULL,
+	project    TEXT NOT NULL,
+	task       TEXT NOT NULL DEFAULT '',
+	start_at   TEXT NOT NULL,
+	end_at     TEXT
+);
+CREATE INDEX IF NOT EXISTS tags_session_id_seq ON tags (session_id, seq);
+CREATE TABLE IF NOT EXISTS activities (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_id INTEGER NOT NULL REFERENCES sessions (id),
+	seq        INTEGER NOT NULL,
+	label      TEXT NOT NULL,
+	start_at   TEXT NOT NULL,
+	end_at     TEXT
+);
+CREATE INDEX IF NOT EXISTS activities_session_id_seq ON activities (session_id, seq);
+`
+
+// NewSQLiteRoudoReportRepository は労働を sessions テーブル、休憩を breaks テーブル、プロジェクトを tags テーブル、
+// 自動で推定した作業内容を activities テーブルに保存する
+func NewSQLiteRoudoReportRepository(db *sql.DB) (RoudoReportRepository, error) {
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return nil, err
+	}
+	return &sqliteRoudoRepository{db: db}, nil
+}
+
+type sqliteRoudoRepository struct {
+	db *sql.DB
+}
+
+func (r *sqliteRoudoRepository) SaveCurrentState(s RoudoState) error {
+	return r.setState(CurrentStateKey, string(s))
+}
+
+func (r *sqliteRoudoRepository) GetCurrentState() (RoudoState, error) {
+	v, err := r.getState(CurrentStateKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return RoudoStateOff, nil
+	} else if err != nil {
+		return "", err
+	}
+	return RoudoState(v), nil
+}
+
+func (r *sqliteRoudoRepository) SaveLastEventAt(rt RoudoTime) error {
+	return r.setState(LastEventAtKey, rt.t.Format(time.RFC3339))
+}
+
+func (r *sqliteRoudoRepository) GetLastEventAt() (*RoudoTime, error) {
+	v, err := r.getState(LastEventAtKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+	rt := NewRoudoTime(t, 0)
+	return &rt, nil
+}
+
+func (r *sqliteRoudoRepository) setState(key, value string) error {
+	_, err := r.db.Exec(`INSERT INTO states (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value`, key, value)
+	return err
+}
+
+func (r *sqliteRoudoRepository) getState(key string) (string, error) {
+	var v string
+	err := r.db.QueryRow(`SELECT value FROM states WHERE key = ?`, key).Scan(&v)
+	return v, err
+}
+
+func (r *sqliteRoudoRepository) SaveRoudoReport(date Date, rs []Roudo) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM breaks WHERE session_id IN (SELECT id FROM sessions WHERE date = ?)`, string(date)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM tags WHERE session_id IN (SELECT id FROM sessions WHERE date = ?)`, string(date)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM activities WHERE session_id IN (SELECT id FROM sessions WHERE date = ?)`, string(date)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM sessions WHERE date = ?`, string(date)); err != nil {
+		return err
+	}
+
+	for i, ro := range rs {
+		res, err := tx.Exec(`INSERT INTO sessions (date, seq, start_at, end_at) VALUES (?, ?, ?, ?)`,
+			string(date), i, formatNullTime(ro.StartAt), formatNullTime(ro.EndAt))
+		if err != nil {
+			return err
+		}
+		sessionID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		for j, b := range ro.Breaks {
+			if _, err := tx.Exec(`INSERT INTO breaks (session_id, seq, start_at, end_at) VALUES (?, ?, ?, ?)`,
+				sessionID, j, b.StartAt.Format(time.RFC3339Nano), formatNullTime(b.EndAt)); err != nil {
+				return err
+			}
+		}
+		for j, tag := range ro.Tags {
+			if _, err := tx.Exec(`INSERT INTO tags (session_id, seq, project, task, start_at, end_at) VALUES (?, ?, ?, ?, ?, ?)`,
+				sessionID, j, tag.Project, tag.Task, tag.StartAt.Format(time.RFC3339Nano), formatNullTime(tag.EndAt)); err != nil {
+				return err
+			}
+		}
+		for j, a := range ro.Activities {
+			if _, err := tx.Exec(`INSERT INTO activities (session_id, seq, label, start_at, end_at) VALUES (?, ?, ?, ?, ?)`,
+				sessionID, j, a.Label, a.StartAt.Format(time.RFC3339Nano), formatNullTime(a.EndAt)); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *sqliteRoudoRepository) GetRoudoReport(date Date) ([]Roudo, error) {
+	reports, err := r.ListRoudoReports(date, date)
+	if err != nil {
+		return nil, err
+	}
+	return reports[date], nil
+}
+
+// sessionsInRange は期間内の労働の休憩・プロジェクト・作業内容をまとめて取得するための条件
+const sessionsInRange = `session_id IN (SELECT id FROM sessions WHERE date BETWEEN ? AND ?)`
+
+func (r *sqliteRoudoRepository) ListRoudoReports(from, to Date) (map[Date][]Roudo, error) {
+	reports, sessions, err := r.listSessions(from, to)
+	if err != nil {
+		return nil, err
+	}
+	// 休憩などは sessions の id から、reports の中の労働を引いて追加する
+	session := func(id int64) *Roudo {
+		ref := sessions[id]
+		return &reports[ref.date][ref.index]
+	}
+	if err := r.listBreaks(from, to, session); err != nil {
+		return nil, err
+	}
+	if err := r.listTags(from, to, session); err != nil {
+		return nil, err
+	}
+	if err := r.listActivities(from, to, session); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+type sqliteSessionRef struct {
+	date  Date
+	index int
+}
+
+func (r *sqliteRoudoRepository) listSessions(from, to Date) (map[Date][]Roudo, map[int64]sqliteSessionRef, error) {
+	rows, err := r.db.Query(`SELECT id, date, start_at, end_at FROM sessions WHERE date BETWEEN ? AND ? ORDER BY date, seq`, string(from), string(to))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	reports := make(map[Date][]Roudo)
+	sessions := make(map[int64]sqliteSessionRef)
+	for rows.Next() {
+		var (
+			id         int64
+			date       string
+			start, end sql.NullString
+		)
+		if err := rows.Scan(&id, &date, &start, &end); err != nil {
+			return nil, nil, err
+		}
+		ro := Roudo{}
+		if ro.StartAt, err = parseNullTime(start); err != nil {
+			return nil, nil, err
+		}
+		if ro.EndAt, err = parseNullTime(end); err != nil {
+			return nil, nil, err
+		}
+		d := Date(date)
+		reports[d] = append(reports[d], ro)
+		sessions[id] = sqliteSessionRef{date: d, index: len(reports[d]) - 1}
+	}
+	return reports, sessions, rows.Err()
+}
+
+func (r *sqliteRoudoRepository) listBreaks(from, to Date, session func(id int64) *Roudo) error {
+	rows, err := r.db.Query(`SELECT session_id, start_at, end_at FROM breaks WHERE `+sessionsInRange+` ORDER BY session_id, seq`, string(from), string(to))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			sessionID int64
+			start     string
+			end       sql.NullString
+		)
+		if err := rows.Scan(&sessionID, &start, &end); err != nil {
+			return err
+		}
+		s, err := time.Parse(time.RFC3339Nano, start)
+		if err != nil {
+			return err
+		}
+		e, err := parseNullTime(end)
+		if err != nil {
+			return err
+		}
+		ro := session(sessionID)
+		ro.Breaks = append(ro.Breaks, Break{StartAt: s, EndAt: e})
+	}
+	return rows.Err()
+}
+
+func (r *sqliteRoudoRepository) listTags(from, to Date, session func(id int64) *Roudo) error {
+	rows, err := r.db.Query(`SELECT session_id, project, task, start_at, end_at FROM tags WHERE `+sessionsInRange+` ORDER BY session_id, seq`, string(from), string(to))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			sessionID int64
+			tag       Tag
+			start     string
+			end       sql.NullString
+		)
+		if err := rows.Scan(&sessionID, &tag.Project, &tag.Task, &start, &end); err != nil {
+			return err
+		}
+		if tag.StartAt, err = time.Parse(time.RFC3339Nano, start); err != nil {
+			return err
+		}
+		if tag.EndAt, err = parseNullTime(end); err != nil {
+			return err
+		}
+		ro := session(sessionID)
+		ro.Tags = append(ro.Tags, tag)
+	}
+	return rows.Err()
+}
+
+func (r *sqliteRoudoRepository) listActivities(from, to Date, session func(id int64) *Roudo) error {
+	rows, err := r.db.Query(`SELECT session_id, label, start_at, end_at FROM activities WHERE `+sessionsInRange+` ORDER BY session_id, seq`, string(from), string(to))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			sessionID int64
+			a         Activity
+			start     string
+			end       sql.NullString
+		)
+		if err := rows.Scan(&sessionID, &a.Label, &start, &end); err != nil {
+			return err
+		}
+		if a.StartAt, err = time.Parse(time.RFC3339Nano, start); err != nil {
+			return err
+		}
+		if a.EndAt, err = parseNullTime(end); err != nil {
+			return err
+		}
+		ro := session(sessionID)
+		ro.Activities = append(ro.Activities, a)
+	}
+	return rows.Err()
+}
+
+func (r *sqliteRoudoRepository) ListDates() ([]Date, error) {
+	rows, err := r.db.Query(`SELECT DISTINCT date FROM sessions ORDER BY date`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dates []Date
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		dates = append(dates, Date(d))
+	}
+	return dates, rows.Err()
+}
+
+func formatNullTime(t *time.Time) sql.NullString {
+	if t == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.Format(time.RFC3339Nano), Valid: true}
+}
+
+func parseNullTime(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,170 @@
+package roudo
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tidwall/buntdb"
+
+	_ "modernc.org/sqlite"
+)
+
+// TestRoudoReportRepositoryConformance は保存先によらず同じように読み書きできることを確かめる
+func TestRoudoReportRepositoryConformance(t *testing.T) {
+	repositories := []struct {
+		name string
+		open func(t *testing.T) RoudoReportRepository
+	}{
+		{"buntdb", func(t *testing.T) RoudoReportRepository {
+			db, err := buntdb.Open(filepath.Join(t.TempDir(), "roudo.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Close() })
+			return NewRoudoReportRepository(db)
+		}},
+		{"sqlite", func(t *testing.T) RoudoReportRepository {
+			db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "roudo.sqlite"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { db.Close() })
+			repo, err := NewSQLiteRoudoReportRepository(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return repo
+		}},
+		{"json", func(t *testing.T) RoudoReportRepository {
+			repo, err := NewJSONRoudoReportRepository(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return repo
+		}},
+		{"memory", func(t *testing.T) RoudoReportRepository {
+			return NewMemoryRoudoReportRepository()
+		}},
+	}
+
+	at := func(month time.Month, day, hour, min int) *time.Time {
+		t := time.Date(2024, month, day, hour, min, 0, 0, time.Local)
+		return &t
+	}
+	reports := map[Date][]Roudo{
+		"2024-02-29": {{
+			StartAt: at(2, 29, 9, 0),
+			EndAt:   at(2, 29, 18, 0),
+			Breaks:  []Break{{StartAt: *at(2, 29, 12, 0), EndAt: at(2, 29, 13, 0)}},
+			Tags: []Tag{
+				{Project: "roudo", Task: "review", StartAt: *at(2, 29, 9, 0), EndAt: at(2, 29, 12, 0)},
+				{Project: "other", StartAt: *at(2, 29, 13, 0), EndAt: at(2, 29, 18, 0)},
+			},
+			Activities: []Activity{{Label: "vim", StartAt: *at(2, 29, 9, 0), EndAt: at(2, 29, 12, 0)}},
+		}},
+		// 進行中の労働・休憩・プロジェクト・作業内容は終了時刻がない
+		"2024-03-01": {
+			{StartAt: at(3, 1, 9, 0), EndAt: at(3, 1, 10, 0)},
+			{
+				StartAt:    at(3, 1, 11, 0),
+				Breaks:     []Break{{StartAt: *at(3, 1, 12, 0)}},
+				Tags:       []Tag{{Project: "roudo", StartAt: *at(3, 1, 11, 0)}},
+				Activities: []Activity{{Label: "vim", StartAt: *at(3, 1, 11, 0)}},
+			},
+		},
+	}
+
+	for _, tt := range repositories {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := tt.open(t)
+			for date, rs := range reports {
+				if err := repo.SaveRoudoReport(date, rs); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// 空の記録は記録のない日として扱う
+			if err := repo.SaveRoudoReport("2024-03-02", []Roudo{}); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.SaveRoudoReport("2024-03-03", []Roudo{{StartAt: at(3, 3, 9, 0)}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.SaveRoudoReport("2024-03-03", nil); err != nil {
+				t.Fatal(err)
+			}
+
+			for date, want := range reports {
+				got, err := repo.GetRoudoReport(date)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if same, _ := sameRoudos(got, want); !same {
+					t.Errorf("GetRoudoReport(%s) = %+v, want %+v", date, got, want)
+				}
+			}
+			for _, date := range []Date{"2024-03-02", "2024-03-03", "2024-03-04"} {
+				got, err := repo.GetRoudoReport(date)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(got) != 0 {
+					t.Errorf("GetRoudoReport(%s) = %+v, want empty", date, got)
+				}
+			}
+
+			dates, err := repo.ListDates()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []Date{"2024-02-29", "2024-03-01"}; !reflect.DeepEqual(dates, want) {
+				t.Errorf("ListDates = %v, want %v", dates, want)
+			}
+
+			ranges := []struct {
+				from, to Date
+				want     []Date
+			}{
+				{"2024-02-01", "2024-03-31", []Date{"2024-02-29", "2024-03-01"}},
+				{"2024-02-29", "2024-02-29", []Date{"2024-02-29"}},
+				{"2024-03-01", "2024-03-31", []Date{"2024-03-01"}},
+				{"2024-03-02", "2024-04-30", nil},
+			}
+			for _, r := range ranges {
+				got, err := repo.ListRoudoReports(r.from, r.to)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(got) != len(r.want) {
+					t.Errorf("ListRoudoReports(%s, %s) = %d days, want %v", r.from, r.to, len(got), r.want)
+				}
+				for _, date := range r.want {
+					if same, _ := sameRoudos(got[date], reports[date]); !same {
+						t.Errorf("ListRoudoReports(%s, %s)[%s] = %+v, want %+v", r.from, r.to, date, got[date], reports[date])
+					}
+				}
+			}
+
+			if s, err := repo.GetCurrentState(); err != nil || s != RoudoStateOff {
+				t.Errorf("GetCurrentState = %s, %v, want %s", s, err, RoudoStateOff)
+			}
+			if rt, err := repo.GetLastEventAt(); err != nil || rt != nil {
+				t.Errorf("GetLastEventAt = %v, %v, want nil", rt, err)
+			}
+			if err := repo.SaveCurrentState(RoudoStateBreaking); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.SaveLastEventAt(NewRoudoTime(*at(3, 1, 12, 0), 0)); err != nil {
+				t.Fatal(err)
+			}
+			if s, err := repo.GetCurrentState(); err != nil || s != RoudoStateBreaking {
+				t.Errorf("GetCurrentState = %s, %v, want %s", s, err, RoudoStateBreaking)
+			}
+			if rt, err := repo.GetLastEventAt(); err != nil || rt == nil || !rt.Time().Equal(*at(3, 1, 12, 0)) {
+				t.Errorf("GetLastEventAt = %v, %v, want %s", rt, err, at(3, 1, 12, 0))
+			}
+		})
+	}
+}
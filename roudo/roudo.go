@@ -6,6 +6,9 @@ type Roudo struct {
 	StartAt *time.Time `json:"start_at"`
 	EndAt   *time.Time `json:"end_at"`
 	Breaks  []Break    `json:"breaks"`
+	Tags    []Tag      `json:"tags,omitempty"`
+	// 自動で推定した作業内容
+	Activities []Activity `json:"activities,omitempty"`
 }
 
 func (r *Roudo) TotalWorkingTime() time.Duration {
@@ -32,3 +35,36 @@ func (r *Roudo) TotalBreakTime() time.Duration {
 	}
 	return total
 }
+
+// WorkingTimeAt は now 時点での労働時間を返す。終了していない労働や休憩は now まで続いているものとみなす
+func (r *Roudo) WorkingTimeAt(now time.Time) time.Duration {
+	if r.StartAt == nil {
+		return 0
+	}
+	end := now
+	if r.EndAt != nil {
+		end = *r.EndAt
+	}
+	return end.Sub(*r.StartAt) - r.BreakTimeAt(end)
+}
+
+// BreakTimeAt は now 時点での休憩時間を返す。終了していない休憩は now まで続いているものとみなす
+func (r *Roudo) BreakTimeAt(now time.Time) time.Duration {
+	var total time.Duration
+	for _, b := range r.Breaks {
+		end := now
+		if b.EndAt != nil {
+			end = *b.EndAt
+		}
+		if end.After(b.StartAt) {
+			total += end.Sub(b.StartAt)
+		}
+	}
+	return total
+}
+
+// DailyReport は日付ごとの労働記録
+type DailyReport struct {
+	Date   Date
+	Roudos []Roudo
+}
@@ -1,98 +1,538 @@
 package roudo
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
+	"roudo/roudo_event"
+	"sync"
 	"time"
-
-	"github.com/alexflint/go-filemutex"
 )
 
 type RoudoReporter interface {
-	HandleRoudoEvent() error
-	Kansi() error
-	SaveRoudoReport(date Date, rs []Roudo) error
+	// 入力イベントを e.At の時刻に受け取ったものとして扱い、処理後の状態を返す
+	HandleRoudoEvent(e roudo_event.Event) (RoudoState, error)
+	// 画面ロック・スリープの開始と終了。ロック中は休憩とし、入力イベントは無視する
+	HandleSessionEvent(kind roudo_event.Kind, at time.Time) error
+	// 労働中であれば、ctx から推定した作業内容を記録する
+	HandleActivityContext(ctx roudo_event.ActivityContext) error
+	// 最終イベントからの経過時間で休憩・労働終了に切り替え、処理後の状態を返す
+	Kansi() (RoudoState, error)
+	// kansi の終了時に action に応じて、進行中の労働を最終イベント時刻で休憩または終了にする。自動判定として監査ログに残る
+	Exit(action ExitAction) error
+	// src の全ての労働記録と状態を写し、写した日数を返す。書き換えは AuditSourceMigrate として監査ログに残る
+	Migrate(src RoudoReportRepository) (int, error)
+
+	RoudoEditor
+	RoudoController
+}
+
+// ErrReportChanged は読み込んだ後に、労働記録が他で書き換えられていたことを表す
+var ErrReportChanged = errors.New("労働記録が他で更新されています")
+
+// RoudoEditor は保存されている労働記録の参照と編集。デーモン経由でも同じように操作できる
+type RoudoEditor interface {
+	RoudoReportLister
+	// 手動で編集した労働記録を保存する。書き換えは source と共に監査ログに残る
+	SaveRoudoReport(date Date, rs []Roudo, source AuditSource) error
+	// 保存されている労働記録が expected のままであれば rs に書き換える。他で書き換えられていれば ErrReportChanged を返す
+	ReplaceRoudoReport(date Date, expected, rs []Roudo, source AuditSource) error
+	// 保存されている全ての労働記録と状態の不整合を返す。fix なら安全に直せるものを直す
+	Doctor(fix bool) ([]Finding, error)
 }
 
-func NewRoudoReporter(repo RoudoReportRepository, logger *slog.Logger, notificator Notificator, fm *filemutex.FileMutex) RoudoReporter {
+// RoudoController は手動での打刻と状態の取得。デーモン経由でも同じように操作できる
+type RoudoController interface {
+	// 手動での打刻。at に時刻を遡って指定できる
+	StartWorking(at time.Time) error
+	FinishWorking(at time.Time) error
+	StartBreaking(at time.Time) error
+	FinishBreaking(at time.Time) error
+	// 進行中の労働に at からのプロジェクト・タスクを記録する。project が空なら記録を止める
+	Tag(at time.Time, project, task string) error
+
+	GetStatus(now time.Time) (*RoudoStatus, error)
+}
+
+func NewRoudoReporter(repo RoudoReportRepository, audit AuditLog, logger *slog.Logger, notificator Notificator, mux Mutex, clock Clock, cfg Config) RoudoReporter {
 	return &roudoReport{
 		repo:                  repo,
-		mux:                   fm,
+		audit:                 audit,
+		mux:                   mux,
 		notificator:           notificator,
-		shiftDuration:         5 * time.Hour,
-		startBreakInterval:    35 * time.Minute,
-		finishWorkingInterval: 4 * time.Hour,
+		clock:                 clock,
+		shiftDuration:         cfg.ShiftDuration,
+		startBreakInterval:    cfg.StartBreakInterval,
+		finishWorkingInterval: cfg.FinishWorkingInterval,
+		lockFinishInterval:    cfg.LockFinishWorkingInterval,
+		activityRules:         cfg.ActivityRules,
 		logger:                logger,
 	}
 }
 
 type roudoReport struct {
 	repo                  RoudoReportRepository
-	mux                   *filemutex.FileMutex
+	audit                 AuditLog
+	mux                   Mutex
 	notificator           Notificator
+	clock                 Clock
 	shiftDuration         time.Duration
 	startBreakInterval    time.Duration
 	finishWorkingInterval time.Duration
+	lockFinishInterval    time.Duration
+	activityRules         []ActivityRule
 	logger                *slog.Logger
+
+	// mux はプロセス間の排他なので、デーモンの RPC と監視の間は mu で排他する
+	mu sync.Mutex
+	// 保持中のロックで労働記録を書き換えているもの
+	source AuditSource
+
+	// 画面ロック・スリープの状態は kansi のプロセス内でだけ保持する
+	sessionMu   sync.Mutex
+	locked      bool
+	sleeping    bool
+	suspendedAt *RoudoTime
 }
 
-func (r *roudoReport) HandleRoudoEvent() error {
-	r.mux.Lock()
-	defer r.mux.Unlock()
+func (r *roudoReport) HandleRoudoEvent(e roudo_event.Event) (RoudoState, error) {
+	r.lock(AuditSourceAuto)
+	defer r.unlock()
+
+	logger := r.logger.With(slog.String("source", e.Source), slog.String("kind", string(e.Kind)))
+	if r.suspended() != nil {
+		logger.Debug("ignore roudo event while suspended")
+		return r.repo.GetCurrentState()
+	}
 
-	r.logger.Debug("handle roudo event!")
+	logger.Debug("handle roudo event!", slog.Any("meta", e.Meta))
 
-	if err := r.repo.SaveLastEventAt(NewRoudoTime(time.Now(), r.shiftDuration)); err != nil {
-		return err
+	at := e.At
+	if now := r.clock.Now(); at.IsZero() || at.After(now) {
+		at = now
+	}
+	// 最終イベント時刻を巻き戻さないよう、遅れて届いたイベントは最終イベント時刻に揃える
+	lastEventAt, err := r.lastEventAt()
+	if err != nil {
+		return "", err
+	}
+	if lastEventAt != nil && at.Before(*lastEventAt.Time()) {
+		at = *lastEventAt.Time()
+	}
+
+	t := NewRoudoTime(at, r.shiftDuration)
+	if err := r.repo.SaveLastEventAt(t); err != nil {
+		return "", err
 	}
 
 	s, err := r.repo.GetCurrentState()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	t := NewRoudoTime(time.Now(), r.shiftDuration)
 	switch s {
 	case RoudoStateOff:
-		return r.startNewWorking(t)
+		if err := r.startNewWorking(t); err != nil {
+			return "", err
+		}
+		return RoudoStateWorking, nil
 	case RoudoStateBreaking:
-		return r.finishBreaking()
+		if err := r.finishBreaking(t); err != nil {
+			return "", err
+		}
+		return RoudoStateWorking, nil
 	}
 
-	return nil
+	return s, nil
 }
 
-func (r *roudoReport) Kansi() error {
-	r.mux.Lock()
-	defer r.mux.Unlock()
+func (r *roudoReport) HandleSessionEvent(kind roudo_event.Kind, at time.Time) error {
+	r.lock(AuditSourceAuto)
+	defer r.unlock()
+	r.sessionMu.Lock()
+	defer r.sessionMu.Unlock()
+
+	r.logger.Debug("handle session event", slog.String("kind", string(kind)))
+
+	switch kind {
+	case roudo_event.KindLock:
+		r.locked = true
+	case roudo_event.KindUnlock:
+		r.locked = false
+	case roudo_event.KindSleep:
+		r.sleeping = true
+	case roudo_event.KindWake:
+		r.sleeping = false
+	default:
+		return fmt.Errorf("未対応のセッションイベントです: %s", kind)
+	}
+
+	t := NewRoudoTime(at, r.shiftDuration)
+	if r.locked || r.sleeping {
+		// ロック中にスリープした場合などは、最初にロック・スリープした時刻を使う
+		if r.suspendedAt != nil {
+			return nil
+		}
+		r.suspendedAt = &t
+		return r.suspend(t)
+	}
+
+	if r.suspendedAt == nil {
+		return nil
+	}
+	suspendedAt := *r.suspendedAt
+	r.suspendedAt = nil
+	return r.resume(suspendedAt, t, kind == roudo_event.KindUnlock)
+}
+
+// suspended はロック・スリープ中ならその開始時刻を返す
+func (r *roudoReport) suspended() *RoudoTime {
+	r.sessionMu.Lock()
+	defer r.sessionMu.Unlock()
+	return r.suspendedAt
+}
+
+// suspend は労働中なら t から休憩を始める
+func (r *roudoReport) suspend(t RoudoTime) error {
+	s, err := r.repo.GetCurrentState()
+	if err != nil {
+		return err
+	}
+	if s != RoudoStateWorking {
+		return nil
+	}
+	if err := r.repo.SaveLastEventAt(t); err != nil {
+		return err
+	}
+	return r.startBreaking(t)
+}
 
+// resume はロック・スリープが lockFinishInterval 以上続いていれば最終イベント時刻で労働を終了する。
+// そうでなければロック解除の時点で休憩を終える。スリープからの復帰だけでは席に戻ったとは限らないので、次の入力イベントを待つ
+func (r *roudoReport) resume(suspendedAt, t RoudoTime, unlocked bool) error {
 	s, err := r.repo.GetCurrentState()
 	if err != nil {
 		return err
 	}
+	if s != RoudoStateBreaking {
+		return nil
+	}
+
+	if t.Time().Sub(*suspendedAt.Time()) >= r.lockFinishInterval || t.IsOvernight(suspendedAt) {
+		lastEventAt, err := r.lastEventAt()
+		if err != nil {
+			return err
+		}
+		if lastEventAt == nil || lastEventAt.Time().After(*suspendedAt.Time()) {
+			lastEventAt = &suspendedAt
+		}
+		return r.finishWorking(*lastEventAt)
+	}
+
+	if !unlocked {
+		return nil
+	}
+	if err := r.repo.SaveLastEventAt(t); err != nil {
+		return err
+	}
+	return r.finishBreaking(t)
+}
+
+func (r *roudoReport) Kansi() (RoudoState, error) {
+	r.lock(AuditSourceAuto)
+	defer r.unlock()
+
+	s, err := r.repo.GetCurrentState()
+	if err != nil {
+		return "", err
+	}
 
 	r.logger.Debug("kansi", slog.String("state", string(s)))
 
-	t := NewRoudoTime(time.Now(), r.shiftDuration)
+	t := NewRoudoTime(r.clock.Now(), r.shiftDuration)
 	switch s {
 	case RoudoStateWorking:
-		return r.kansiWorking(t)
+		err = r.kansiWorking(t)
 	case RoudoStateBreaking:
-		return r.kansiBreaking(t)
+		err = r.kansiBreaking(t)
+	default:
+		return s, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return r.repo.GetCurrentState()
+}
+
+func (r *roudoReport) ListRoudoReports(from, to Date) (map[Date][]Roudo, error) {
+	r.lock("")
+	defer r.unlock()
+
+	return r.repo.ListRoudoReports(from, to)
+}
+
+func (r *roudoReport) SaveRoudoReport(date Date, rs []Roudo, source AuditSource) error {
+	r.lock(source)
+	defer r.unlock()
+
+	return r.saveRoudoReport(date, rs)
+}
+
+func (r *roudoReport) ReplaceRoudoReport(date Date, expected, rs []Roudo, source AuditSource) error {
+	r.lock(source)
+	defer r.unlock()
+
+	current, err := r.repo.GetRoudoReport(date)
+	if err != nil {
+		return err
+	}
+	same, err := sameRoudos(current, expected)
+	if err != nil {
+		return err
+	}
+	if !same {
+		return fmt.Errorf("%s: %w", date, ErrReportChanged)
+	}
+	return r.saveRoudoReport(date, rs)
+}
+
+func (r *roudoReport) Exit(action ExitAction) error {
+	r.lock(AuditSourceAuto)
+	defer r.unlock()
+
+	s, err := r.repo.GetCurrentState()
+	if err != nil {
+		return err
+	}
+	lastEventAt, err := r.lastEventAt()
+	if err != nil {
+		return err
+	}
+	t := NewRoudoTime(r.clock.Now(), r.shiftDuration)
+	if lastEventAt != nil {
+		t = *lastEventAt
 	}
 
+	switch {
+	case action == ExitActionBreak && s == RoudoStateWorking:
+		r.logger.Info("start breaking on exit")
+		return r.startBreaking(t)
+	case action == ExitActionFinish && s != RoudoStateOff:
+		r.logger.Info("finish working on exit")
+		return r.finishWorking(t)
+	}
 	return nil
 }
 
-func (r *roudoReport) SaveRoudoReport(date Date, rs []Roudo) error {
+// lock は労働記録を操作する間の排他を取る。source はこの間の書き換えとして監査ログに残る
+func (r *roudoReport) lock(source AuditSource) {
+	r.mu.Lock()
 	r.mux.Lock()
-	defer r.mux.Unlock()
+	r.source = source
+}
+
+func (r *roudoReport) unlock() {
+	r.source = ""
+	r.mux.Unlock()
+	r.mu.Unlock()
+}
 
-	return r.repo.SaveRoudoReport(date, rs)
+// saveRoudoReport は労働記録を保存し、変更があれば監査ログに追記する
+func (r *roudoReport) saveRoudoReport(date Date, rs []Roudo) error {
+	before, err := r.repo.GetRoudoReport(date)
+	if err != nil {
+		return err
+	}
+	// 自動判定は監視を止めないように、移行は元の記録をそのまま写すように検証しない
+	if r.source != AuditSourceAuto && r.source != AuditSourceMigrate && r.source != "" {
+		issues := NewValidator(r.shiftDuration).ValidateChange(date, before, rs)
+		if err := issues.Err(); err != nil {
+			return err
+		}
+		for _, w := range issues.Warnings() {
+			r.logger.Warn("inconsistent roudo report", slog.String("date", string(date)), slog.String("warning", w))
+		}
+	}
+	e, changed, err := newAuditEntry(r.clock.Now(), r.source, date, before, rs)
+	if err != nil {
+		return err
+	}
+	if err := r.repo.SaveRoudoReport(date, rs); err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	// 監査ログに残らない書き換えを作らないよう、追記できなければ元に戻す
+	if err := r.audit.Append(e); err != nil {
+		err = fmt.Errorf("監査ログの書き込みに失敗しました: %w", err)
+		if rollbackErr := r.repo.SaveRoudoReport(date, before); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("%s の労働記録を元に戻せませんでした: %w", date, rollbackErr))
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *roudoReport) StartWorking(at time.Time) error {
+	r.lock(AuditSourceCLI)
+	defer r.unlock()
+
+	s, err := r.repo.GetCurrentState()
+	if err != nil {
+		return err
+	}
+	if s != RoudoStateOff {
+		return fmt.Errorf("既に労働中です (current_state: %s)", s)
+	}
+
+	t := NewRoudoTime(at, r.shiftDuration)
+	if err := r.saveManualEventAt(at); err != nil {
+		return err
+	}
+	return r.startNewWorking(t)
+}
+
+func (r *roudoReport) FinishWorking(at time.Time) error {
+	r.lock(AuditSourceCLI)
+	defer r.unlock()
+
+	s, err := r.repo.GetCurrentState()
+	if err != nil {
+		return err
+	}
+	if s == RoudoStateOff {
+		return fmt.Errorf("労働中ではありません")
+	}
+
+	t := NewRoudoTime(at, r.shiftDuration)
+	rs, err := r.currentRoudos(t)
+	if err != nil {
+		return err
+	}
+
+	// 休憩中に手動で終了した場合は、指定時刻までを休憩として残す
+	current := &rs[len(rs)-1]
+	if len(current.Breaks) != 0 {
+		lastBreak := &current.Breaks[len(current.Breaks)-1]
+		if lastBreak.EndAt == nil && lastBreak.StartAt.Before(at) {
+			lastBreak.EndAt = t.Time()
+			if err := r.saveRoudoReport(t.ShiftedDate(), rs); err != nil {
+				return err
+			}
+		}
+	}
+	return r.finishWorking(t)
+}
+
+func (r *roudoReport) StartBreaking(at time.Time) error {
+	r.lock(AuditSourceCLI)
+	defer r.unlock()
+
+	s, err := r.repo.GetCurrentState()
+	if err != nil {
+		return err
+	}
+	if s != RoudoStateWorking {
+		return fmt.Errorf("労働中ではありません (current_state: %s)", s)
+	}
+
+	t := NewRoudoTime(at, r.shiftDuration)
+	if _, err := r.currentRoudos(t); err != nil {
+		return err
+	}
+	return r.startBreaking(t)
+}
+
+func (r *roudoReport) FinishBreaking(at time.Time) error {
+	r.lock(AuditSourceCLI)
+	defer r.unlock()
+
+	s, err := r.repo.GetCurrentState()
+	if err != nil {
+		return err
+	}
+	if s != RoudoStateBreaking {
+		return fmt.Errorf("休憩中ではありません (current_state: %s)", s)
+	}
+
+	t := NewRoudoTime(at, r.shiftDuration)
+	rs, err := r.currentRoudos(t)
+	if err != nil {
+		return err
+	}
+	breaks := rs[len(rs)-1].Breaks
+	if len(breaks) != 0 && breaks[len(breaks)-1].StartAt.After(at) {
+		return fmt.Errorf("休憩開始時刻 %s より前には再開できません", breaks[len(breaks)-1].StartAt.Format("15:04"))
+	}
+
+	if err := r.saveManualEventAt(at); err != nil {
+		return err
+	}
+	return r.finishBreaking(t)
+}
+
+// saveManualEventAt は手動打刻を最終イベントとして記録する。
+// 時刻を遡って打刻した直後に、遡った時刻から休憩と判定しないよう、現在時刻より前にはしない
+func (r *roudoReport) saveManualEventAt(at time.Time) error {
+	if now := r.clock.Now(); at.Before(now) {
+		at = now
+	}
+	return r.repo.SaveLastEventAt(NewRoudoTime(at, r.shiftDuration))
+}
+
+func (r *roudoReport) Tag(at time.Time, project, task string) error {
+	r.lock(AuditSourceCLI)
+	defer r.unlock()
+
+	s, err := r.repo.GetCurrentState()
+	if err != nil {
+		return err
+	}
+	if s == RoudoStateOff {
+		return fmt.Errorf("労働中ではありません")
+	}
+
+	t := NewRoudoTime(at, r.shiftDuration)
+	rs, err := r.currentRoudos(t)
+	if err != nil {
+		return err
+	}
+	rs[len(rs)-1].tagAt(at, project, task)
+	return r.saveRoudoReport(t.ShiftedDate(), rs)
+}
+
+// currentRoudos は t の日付の労働記録を返す。手動打刻の対象となる進行中の労働がなければエラーを返す
+func (r *roudoReport) currentRoudos(t RoudoTime) ([]Roudo, error) {
+	rs, err := r.repo.GetRoudoReport(t.ShiftedDate())
+	if err != nil {
+		return nil, err
+	}
+	if len(rs) == 0 {
+		return nil, fmt.Errorf("%s の労働記録がありません", t.ShiftedDate())
+	}
+	current := rs[len(rs)-1]
+	if current.EndAt != nil {
+		return nil, fmt.Errorf("%s に進行中の労働がありません", t.ShiftedDate())
+	}
+	if current.StartAt != nil && current.StartAt.After(*t.Time()) {
+		return nil, fmt.Errorf("労働開始時刻 %s より前は指定できません", current.StartAt.Format("15:04"))
+	}
+	return rs, nil
+}
+
+// lastEventAt は最終イベント時刻を返す。リポジトリは日付の切り替わりを知らないので、shiftDuration で日付を判定できるように包み直す
+func (r *roudoReport) lastEventAt() (*RoudoTime, error) {
+	rt, err := r.repo.GetLastEventAt()
+	if err != nil || rt == nil {
+		return nil, err
+	}
+	t := NewRoudoTime(*rt.Time(), r.shiftDuration)
+	return &t, nil
 }
 
 func (r *roudoReport) kansiWorking(now RoudoTime) error {
-	lastEventAt, err := r.repo.GetLastEventAt()
+	lastEventAt, err := r.lastEventAt()
 	if err != nil {
 		return err
 	}
@@ -102,14 +542,6 @@ func (r *roudoReport) kansiWorking(now RoudoTime) error {
 
 	// 労働中に日跨ぎした場合は、最終イベント時刻を前日の労働終了時刻とし、労働を終了する
 	if now.IsOvernight(*lastEventAt) {
-		yesterdayReport, err := r.repo.GetRoudoReport(lastEventAt.ShiftedDate())
-		if err != nil {
-			return err
-		}
-		yesterdayReport[len(yesterdayReport)-1].EndAt = lastEventAt.Time()
-		if err := r.repo.SaveRoudoReport(lastEventAt.ShiftedDate(), yesterdayReport); err != nil {
-			return err
-		}
 		return r.finishWorking(*lastEventAt)
 	}
 
@@ -121,7 +553,7 @@ func (r *roudoReport) kansiWorking(now RoudoTime) error {
 }
 
 func (r *roudoReport) kansiBreaking(now RoudoTime) error {
-	lastEventAt, err := r.repo.GetLastEventAt()
+	lastEventAt, err := r.lastEventAt()
 	if err != nil {
 		return err
 	}
@@ -131,14 +563,6 @@ func (r *roudoReport) kansiBreaking(now RoudoTime) error {
 
 	// 休憩時間中に日跨ぎをした場合、最終イベント時刻を前日の労働終了時刻とし、労働を終了する
 	if now.IsOvernight(*lastEventAt) {
-		yesterdayReport, err := r.repo.GetRoudoReport(lastEventAt.ShiftedDate())
-		if err != nil {
-			return err
-		}
-		yesterdayReport[len(yesterdayReport)-1].EndAt = lastEventAt.Time()
-		if err := r.repo.SaveRoudoReport(lastEventAt.ShiftedDate(), yesterdayReport); err != nil {
-			return err
-		}
 		return r.finishWorking(*lastEventAt)
 	}
 
@@ -146,33 +570,39 @@ func (r *roudoReport) kansiBreaking(now RoudoTime) error {
 		return r.finishWorking(*lastEventAt)
 	}
 
+	// ロック・スリープ中は lockFinishInterval で労働終了とみなす
+	if suspendedAt := r.suspended(); suspendedAt != nil && !now.Time().Before(suspendedAt.Time().Add(r.lockFinishInterval)) {
+		return r.finishWorking(*lastEventAt)
+	}
+
 	return nil
 }
 
 func (r *roudoReport) startNewWorking(t RoudoTime) error {
 	r.logger.Debug("start new working")
-	r.notificator.Notify("労働開始", "よろしくお願いします")
-	if err := r.repo.SaveCurrentState(RoudoStateWorking); err != nil {
-		return err
-	}
+	r.notificator.Notify(NotifyEventStartWorking, "労働開始", "よろしくお願いします")
 	rs, err := r.repo.GetRoudoReport(t.ShiftedDate())
 	if err != nil {
 		return err
 	}
 	rs = append(rs, Roudo{StartAt: t.Time()})
-	return r.repo.SaveRoudoReport(t.ShiftedDate(), rs)
+	// 記録が検証で拒否された場合に状態だけが変わらないよう、記録を先に保存する
+	if err := r.saveRoudoReport(t.ShiftedDate(), rs); err != nil {
+		return err
+	}
+	return r.repo.SaveCurrentState(RoudoStateWorking)
 }
 
 func (r *roudoReport) finishWorking(endAt RoudoTime) error {
 	r.logger.Debug("finish working")
-	r.notificator.Notify("労働終了", "お疲れ様でした")
-	if err := r.repo.SaveCurrentState(RoudoStateOff); err != nil {
-		return err
-	}
+	r.notificator.Notify(NotifyEventFinishWorking, "労働終了", "お疲れ様でした")
 	report, err := r.repo.GetRoudoReport(endAt.ShiftedDate())
 	if err != nil {
 		return err
 	}
+	if len(report) == 0 || report[len(report)-1].EndAt != nil {
+		return fmt.Errorf("%s に終了する労働がありません。roudo doctor で記録を確認してください", endAt.ShiftedDate())
+	}
 	report[len(report)-1].EndAt = endAt.Time()
 
 	if len(report[len(report)-1].Breaks) != 0 {
@@ -182,14 +612,17 @@ func (r *roudoReport) finishWorking(endAt RoudoTime) error {
 		}
 	}
 
-	return r.repo.SaveRoudoReport(endAt.ShiftedDate(), report)
+	if err := r.saveRoudoReport(endAt.ShiftedDate(), report); err != nil {
+		return err
+	}
+	return r.repo.SaveCurrentState(RoudoStateOff)
 }
 
 func (r *roudoReport) startBreaking(startAt RoudoTime) error {
 	r.logger.Debug("start breaking")
-	r.notificator.Notify("休憩開始", "ゆっくり休んでください")
+	r.notificator.Notify(NotifyEventStartBreaking, "休憩開始", "ゆっくり休んでください")
 
-	rs, err := r.repo.GetRoudoReport(NewRoudoTime(time.Now(), r.shiftDuration).ShiftedDate())
+	rs, err := r.repo.GetRoudoReport(startAt.ShiftedDate())
 	if err != nil {
 		return err
 	}
@@ -197,32 +630,26 @@ func (r *roudoReport) startBreaking(startAt RoudoTime) error {
 		return nil
 	}
 	rs[len(rs)-1].Breaks = append(rs[len(rs)-1].Breaks, Break{StartAt: *startAt.Time()})
-	if err := r.repo.SaveRoudoReport(NewRoudoTime(time.Now(), r.shiftDuration).ShiftedDate(), rs); err != nil {
+	if err := r.saveRoudoReport(startAt.ShiftedDate(), rs); err != nil {
 		return err
 	}
 
 	return r.repo.SaveCurrentState(RoudoStateBreaking)
 }
 
-func (r *roudoReport) finishBreaking() error {
+func (r *roudoReport) finishBreaking(t RoudoTime) error {
 	r.logger.Debug("finish breaking")
-	r.notificator.Notify("休憩終了", "がんばりましょう")
-	if err := r.repo.SaveCurrentState(RoudoStateWorking); err != nil {
-		return err
-	}
-	t := NewRoudoTime(time.Now(), r.shiftDuration)
+	r.notificator.Notify(NotifyEventFinishBreaking, "休憩終了", "がんばりましょう")
 	rs, err := r.repo.GetRoudoReport(t.ShiftedDate())
 	if err != nil {
 		return err
 	}
 
-	if len(rs) == 0 {
-		return nil
-	}
-	if len(rs[len(rs)-1].Breaks) == 0 {
-		return nil
+	if len(rs) != 0 && len(rs[len(rs)-1].Breaks) != 0 {
+		rs[len(rs)-1].Breaks[len(rs[len(rs)-1].Breaks)-1].EndAt = t.Time()
+		if err := r.saveRoudoReport(t.ShiftedDate(), rs); err != nil {
+			return err
+		}
 	}
-	rs[len(rs)-1].Breaks[len(rs[len(rs)-1].Breaks)-1].EndAt = t.Time()
-
-	return r.repo.SaveRoudoReport(t.ShiftedDate(), rs)
+	return r.repo.SaveCurrentState(RoudoStateWorking)
 }
@@ -0,0 +1,98 @@
+package roudo
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestReplaceRoudoReport(t *testing.T) {
+	date := Date("2024-03-01")
+	at := func(hour, min int) *time.Time {
+		t := time.Date(2024, 3, 1, hour, min, 0, 0, time.Local)
+		return &t
+	}
+	edited := []Roudo{{StartAt: at(9, 0), EndAt: at(12, 0)}}
+	// 編集した後に kansi が次の労働を始めた記録
+	stored := []Roudo{{StartAt: at(9, 0), EndAt: at(12, 0)}, {StartAt: at(13, 0)}}
+
+	tests := []struct {
+		name     string
+		expected []Roudo
+		wantErr  error
+		want     []Roudo
+	}{
+		{name: "保存されている記録のままなら書き換える", expected: stored, want: edited},
+		{name: "他で書き換えられていれば書き換えない", expected: edited, wantErr: ErrReportChanged, want: stored},
+		{name: "記録がないはずなのにあれば書き換えない", expected: nil, wantErr: ErrReportChanged, want: stored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reporter, repo, audit, _ := newTestReporter(t, DefaultConfig(), *at(14, 0))
+			if err := repo.SaveRoudoReport(date, stored); err != nil {
+				t.Fatal(err)
+			}
+
+			err := reporter.ReplaceRoudoReport(date, tt.expected, edited, AuditSourceTUI)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			got, err := repo.GetRoudoReport(date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if same, _ := sameRoudos(got, tt.want); !same {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			entries, err := audit.List(date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantEntries := 1
+			if tt.wantErr != nil {
+				wantEntries = 0
+			}
+			if len(entries) != wantEntries {
+				t.Errorf("audit entries = %d, want %d", len(entries), wantEntries)
+			}
+		})
+	}
+}
+
+// failingAuditLog は追記に失敗する AuditLog
+type failingAuditLog struct {
+	AuditLog
+}
+
+func (l failingAuditLog) Append(e AuditEntry) error {
+	return errors.New("disk full")
+}
+
+func TestSaveRoudoReportRollsBackWithoutAudit(t *testing.T) {
+	date := Date("2024-03-01")
+	at := func(hour, min int) *time.Time {
+		t := time.Date(2024, 3, 1, hour, min, 0, 0, time.Local)
+		return &t
+	}
+	stored := []Roudo{{StartAt: at(9, 0), EndAt: at(18, 0)}}
+
+	repo := NewMemoryRoudoReportRepository()
+	if err := repo.SaveRoudoReport(date, stored); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	reporter := NewRoudoReporter(repo, failingAuditLog{NewMemoryAuditLog()}, logger, &NopNotificator{}, NewProcessMutex(), &stubClock{now: *at(20, 0)}, DefaultConfig())
+
+	if err := reporter.SaveRoudoReport(date, []Roudo{{StartAt: at(9, 0), EndAt: at(17, 0)}}, AuditSourceTUI); err == nil {
+		t.Fatal("監査ログに書けないのに保存しました")
+	}
+	got, err := repo.GetRoudoReport(date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same, _ := sameRoudos(got, stored); !same {
+		t.Errorf("監査ログに残らない書き換えが残っています: %+v", got)
+	}
+}
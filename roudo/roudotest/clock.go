@@ -0,0 +1,64 @@
+package roudotest
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock は Set や Advance で進めるまで時刻が止まっている roudo.Clock
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After は時刻が d 以上進められた時に発火するチャネルを返す
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	deadline := c.now.Add(d)
+	if !deadline.After(c.now) {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, waiter{deadline: deadline, ch: ch})
+	return ch
+}
+
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// Set は時刻を t に進め、期限を過ぎた After のチャネルを発火させる
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = t
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(t) {
+			remaining = append(remaining, w)
+			continue
+		}
+		w.ch <- t
+	}
+	c.waiters = remaining
+}
@@ -0,0 +1,38 @@
+package roudotest
+
+import (
+	"roudo/roudo"
+	"sync/atomic"
+)
+
+// CountingRepository は書き込みの回数を数える roudo.RoudoReportRepository。
+// イベントのまとめ方を変えた時に、保存の回数がどれだけ減ったかを比べるのに使う
+type CountingRepository struct {
+	roudo.RoudoReportRepository
+
+	writes atomic.Int64
+}
+
+func NewCountingRepository(repo roudo.RoudoReportRepository) *CountingRepository {
+	return &CountingRepository{RoudoReportRepository: repo}
+}
+
+// Writes はこれまでの書き込みの回数を返す
+func (r *CountingRepository) Writes() int64 {
+	return r.writes.Load()
+}
+
+func (r *CountingRepository) SaveCurrentState(s roudo.RoudoState) error {
+	r.writes.Add(1)
+	return r.RoudoReportRepository.SaveCurrentState(s)
+}
+
+func (r *CountingRepository) SaveLastEventAt(rt roudo.RoudoTime) error {
+	r.writes.Add(1)
+	return r.RoudoReportRepository.SaveLastEventAt(rt)
+}
+
+func (r *CountingRepository) SaveRoudoReport(date roudo.Date, rs []roudo.Roudo) error {
+	r.writes.Add(1)
+	return r.RoudoReportRepository.SaveRoudoReport(date, rs)
+}
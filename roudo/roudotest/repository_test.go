@@ -0,0 +1,88 @@
+package roudotest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"roudo/roudo"
+	"roudo/roudo_event"
+	"testing"
+	"time"
+)
+
+// typeFor は start から1秒ごとに n 回キーを打った時の、リポジトリへの書き込みの回数と最終イベント時刻を返す
+func typeFor(t testing.TB, n int, batchInterval time.Duration) (int64, time.Time) {
+	t.Helper()
+	cfg := roudo.DefaultConfig()
+	cfg.EventBatchInterval = batchInterval
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+
+	repo := NewCountingRepository(roudo.NewMemoryRoudoReportRepository())
+	clock := NewFakeClock(start)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	reporter := roudo.NewRoudoReporter(repo, roudo.NewMemoryAuditLog(), logger, &roudo.NopNotificator{}, roudo.NewProcessMutex(), clock, cfg)
+	watcher := NewFakeWatcher()
+	m := roudo.NewRoudoManager(reporter, []roudo_event.Watcher{watcher}, logger, clock, cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Kansi(ctx)
+	}()
+	for i := 0; i < n; i++ {
+		at := start.Add(time.Duration(i) * time.Second)
+		clock.Set(at)
+		watcher.Send(roudo_event.Event{Source: watcher.Name(), Kind: roudo_event.KindKey, At: at})
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	lastEventAt, err := repo.GetLastEventAt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventAt == nil {
+		t.Fatal("最終イベント時刻が保存されていません")
+	}
+	return repo.Writes(), *lastEventAt.Time()
+}
+
+func TestEventBatchingReducesWrites(t *testing.T) {
+	const events = 600
+	last := time.Date(2024, 3, 1, 9, 0, events-1, 0, time.Local)
+
+	unbatched, unbatchedLast := typeFor(t, events, 0)
+	batched, batchedLast := typeFor(t, events, 10*time.Second)
+	t.Logf("writes for %d events: unbatched %d, batched %d", events, unbatched, batched)
+
+	// まとめなければ入力のたびに最終イベント時刻を書き込む
+	if unbatched < events {
+		t.Errorf("unbatched writes = %d, want >= %d", unbatched, events)
+	}
+	// 10秒ごとにまとめれば、労働開始の書き込みと10秒に1回程度になる
+	if max := int64(events/10 + 10); batched > max {
+		t.Errorf("batched writes = %d, want <= %d", batched, max)
+	}
+	// まとめても最後の入力の時刻は残す
+	for _, got := range []time.Time{unbatchedLast, batchedLast} {
+		if !got.Equal(last) {
+			t.Errorf("last_event_at = %s, want %s", got, last)
+		}
+	}
+}
+
+func BenchmarkEventBatching(b *testing.B) {
+	const events = 600
+	for _, interval := range []time.Duration{0, 10 * time.Second} {
+		b.Run("interval="+interval.String(), func(b *testing.B) {
+			var writes int64
+			for i := 0; i < b.N; i++ {
+				w, _ := typeFor(b, events, interval)
+				writes += w
+			}
+			b.ReportMetric(float64(writes)/float64(b.N*events), "writes/event")
+		})
+	}
+}
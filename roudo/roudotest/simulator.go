@@ -0,0 +1,211 @@
+// Package roudotest は roudoReport の状態遷移を決められた時刻の流れで再現するためのツールを提供する
+package roudotest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"roudo/roudo"
+	"roudo/roudo_event"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Action string
+
+const (
+	// ActionEvent はキーボードやマウスの入力イベント
+	ActionEvent = Action("event")
+	// ActionTick は Kansi のポーリング
+	ActionTick = Action("tick")
+)
+
+type Step struct {
+	At     time.Time
+	Action Action
+}
+
+func Event(at time.Time) Step {
+	return Step{At: at, Action: ActionEvent}
+}
+
+func Tick(at time.Time) Step {
+	return Step{At: at, Action: ActionTick}
+}
+
+// ParseTimeline は1行に「YYYY-MM-DD HH:mm event|tick」の形式で書かれたタイムラインを読み込む。# 以降はコメントとして無視する
+func ParseTimeline(r io.Reader, loc *time.Location) ([]Step, error) {
+	var steps []Step
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text, _, _ := strings.Cut(sc.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%d 行目: 「YYYY-MM-DD HH:mm event|tick」の形式で書いてください", line)
+		}
+		at, err := time.ParseInLocation("2006-01-02 15:04", fields[0]+" "+fields[1], loc)
+		if err != nil {
+			return nil, fmt.Errorf("%d 行目: %w", line, err)
+		}
+		action := Action(fields[2])
+		if action != ActionEvent && action != ActionTick {
+			return nil, fmt.Errorf("%d 行目: 未対応の操作です: %s", line, action)
+		}
+		steps = append(steps, Step{At: at, Action: action})
+	}
+	return steps, sc.Err()
+}
+
+// Simulator はメモリ上のリポジトリと FakeClock を使って roudoReport を動かす
+type Simulator struct {
+	Clock    *FakeClock
+	Repo     roudo.RoudoReportRepository
+	Reporter roudo.RoudoReporter
+
+	pollingInterval time.Duration
+}
+
+func NewSimulator(cfg roudo.Config, start time.Time) (*Simulator, error) {
+	repo := roudo.NewMemoryRoudoReportRepository()
+	clock := NewFakeClock(start)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	reporter := roudo.NewRoudoReporter(repo, roudo.NewMemoryAuditLog(), logger, &roudo.NopNotificator{}, roudo.NewProcessMutex(), clock, cfg)
+
+	return &Simulator{
+		Clock:           clock,
+		Repo:            repo,
+		Reporter:        reporter,
+		pollingInterval: cfg.PollingInterval,
+	}, nil
+}
+
+// Replay は steps を時刻順に実行する。Step の間も pollingInterval ごとに Kansi を呼び、常駐している時のポーリングを再現する
+func (s *Simulator) Replay(steps ...Step) error {
+	steps = slices.Clone(steps)
+	slices.SortStableFunc(steps, func(a, b Step) int { return a.At.Compare(b.At) })
+
+	for _, step := range steps {
+		if err := s.PollUntil(step.At); err != nil {
+			return err
+		}
+		s.Clock.Set(step.At)
+
+		var err error
+		switch step.Action {
+		case ActionEvent:
+			_, err = s.Reporter.HandleRoudoEvent(roudo_event.Event{Source: "roudotest", Kind: roudo_event.KindActive, At: step.At})
+		case ActionTick:
+			_, err = s.Reporter.Kansi()
+		default:
+			err = fmt.Errorf("未対応の操作です: %s", step.Action)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", step.At.Format("2006-01-02 15:04:05"), step.Action, err)
+		}
+	}
+	return nil
+}
+
+// PollUntil は t の直前まで pollingInterval ごとに Kansi を呼ぶ
+func (s *Simulator) PollUntil(t time.Time) error {
+	for next := s.Clock.Now().Add(s.pollingInterval); next.Before(t); next = next.Add(s.pollingInterval) {
+		s.Clock.Set(next)
+		if _, err := s.Reporter.Kansi(); err != nil {
+			return fmt.Errorf("%s tick: %w", next.Format("2006-01-02 15:04:05"), err)
+		}
+	}
+	return nil
+}
+
+// Reports は保存されている全ての労働記録を返す
+func (s *Simulator) Reports() (map[roudo.Date][]roudo.Roudo, error) {
+	dates, err := s.Repo.ListDates()
+	if err != nil {
+		return nil, err
+	}
+	reports := make(map[roudo.Date][]roudo.Roudo)
+	for _, date := range dates {
+		rs, err := s.Repo.GetRoudoReport(date)
+		if err != nil {
+			return nil, err
+		}
+		if len(rs) != 0 {
+			reports[date] = rs
+		}
+	}
+	return reports, nil
+}
+
+// Verify は保存されている労働記録が want と一致しなければ差分を説明するエラーを返す
+func (s *Simulator) Verify(want map[roudo.Date][]roudo.Roudo) error {
+	got, err := s.Reports()
+	if err != nil {
+		return err
+	}
+
+	var dates []roudo.Date
+	for date := range want {
+		dates = append(dates, date)
+	}
+	for date := range got {
+		if _, ok := want[date]; !ok {
+			dates = append(dates, date)
+		}
+	}
+	slices.Sort(dates)
+
+	var diffs []string
+	for _, date := range dates {
+		if !equalRoudos(got[date], want[date]) {
+			diffs = append(diffs, fmt.Sprintf("%s:\n  got:  %s\n  want: %s", date, formatRoudos(got[date]), formatRoudos(want[date])))
+		}
+	}
+	if len(diffs) != 0 {
+		return fmt.Errorf("労働記録が一致しません\n%s", strings.Join(diffs, "\n"))
+	}
+	return nil
+}
+
+func equalRoudos(a, b []roudo.Roudo) bool {
+	return slices.EqualFunc(a, b, func(x, y roudo.Roudo) bool {
+		return equalTimePtr(x.StartAt, y.StartAt) &&
+			equalTimePtr(x.EndAt, y.EndAt) &&
+			slices.EqualFunc(x.Breaks, y.Breaks, func(bx, by roudo.Break) bool {
+				return bx.StartAt.Equal(by.StartAt) && equalTimePtr(bx.EndAt, by.EndAt)
+			})
+	})
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func formatRoudos(rs []roudo.Roudo) string {
+	if len(rs) == 0 {
+		return "(なし)"
+	}
+	var parts []string
+	for _, r := range rs {
+		s := formatTimePtr(r.StartAt) + "~" + formatTimePtr(r.EndAt)
+		for _, b := range r.Breaks {
+			s += " 休憩 " + b.StartAt.Format("15:04:05") + "~" + formatTimePtr(b.EndAt)
+		}
+		parts = append(parts, "["+s+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return "--:--:--"
+	}
+	return t.Format("15:04:05")
+}
@@ -0,0 +1,219 @@
+package roudotest
+
+import (
+	"roudo/roudo"
+	"testing"
+	"time"
+)
+
+func at(day, hour, min int) time.Time {
+	return time.Date(2024, 3, day, hour, min, 0, 0, time.Local)
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
+
+// events は from から to まで (両端を含む) 1分ごとの入力イベントを返す
+func events(from, to time.Time) []Step {
+	var steps []Step
+	for t := from; !t.After(to); t = t.Add(time.Minute) {
+		steps = append(steps, Event(t))
+	}
+	return steps
+}
+
+func concat(stepss ...[]Step) []Step {
+	var steps []Step
+	for _, s := range stepss {
+		steps = append(steps, s...)
+	}
+	return steps
+}
+
+func newTestSimulator(t *testing.T, start time.Time) *Simulator {
+	t.Helper()
+	cfg := roudo.DefaultConfig()
+	cfg.PollingInterval = 30 * time.Second
+	s, err := NewSimulator(cfg, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSimulator(t *testing.T) {
+	tests := []struct {
+		name      string
+		steps     []Step
+		want      map[roudo.Date][]roudo.Roudo
+		wantState roudo.RoudoState
+	}{
+		{
+			name: "日付の切り替わりを跨いで入力が続くと、前日の労働として続ける",
+			steps: concat(
+				events(at(1, 22, 0), at(2, 1, 0)),
+				[]Step{Tick(at(2, 6, 0))},
+			),
+			want: map[roudo.Date][]roudo.Roudo{
+				"2024-03-01": {{StartAt: ptr(at(1, 22, 0)), EndAt: ptr(at(2, 1, 0))}},
+			},
+			wantState: roudo.RoudoStateOff,
+		},
+		{
+			name: "労働中に日付が切り替わると、最終イベント時刻で前日の労働を終了する",
+			steps: concat(
+				events(at(2, 0, 10), at(2, 4, 50)),
+				[]Step{Tick(at(2, 5, 1))},
+				events(at(2, 9, 0), at(2, 9, 30)),
+			),
+			want: map[roudo.Date][]roudo.Roudo{
+				"2024-03-01": {{StartAt: ptr(at(2, 0, 10)), EndAt: ptr(at(2, 4, 50))}},
+				"2024-03-02": {{StartAt: ptr(at(2, 9, 0))}},
+			},
+			wantState: roudo.RoudoStateWorking,
+		},
+		{
+			name: "休憩中に日付が切り替わると、最終イベント時刻で前日の労働を終了する",
+			steps: concat(
+				events(at(2, 2, 0), at(2, 4, 0)),
+				[]Step{Tick(at(2, 5, 30))},
+			),
+			want: map[roudo.Date][]roudo.Roudo{
+				"2024-03-01": {{StartAt: ptr(at(2, 2, 0)), EndAt: ptr(at(2, 4, 0))}},
+			},
+			wantState: roudo.RoudoStateOff,
+		},
+		{
+			name: "入力がなくなると休憩を始め、入力が戻ると休憩を終える",
+			steps: concat(
+				events(at(1, 9, 0), at(1, 10, 0)),
+				events(at(1, 11, 0), at(1, 12, 0)),
+			),
+			want: map[roudo.Date][]roudo.Roudo{
+				"2024-03-01": {{
+					StartAt: ptr(at(1, 9, 0)),
+					Breaks:  []roudo.Break{{StartAt: at(1, 10, 0), EndAt: ptr(at(1, 11, 0))}},
+				}},
+			},
+			wantState: roudo.RoudoStateWorking,
+		},
+		{
+			name: "休憩が続くと最終イベント時刻で労働を終了し、終わっていない休憩は残さない",
+			steps: concat(
+				events(at(1, 9, 0), at(1, 12, 0)),
+				[]Step{Tick(at(1, 16, 1))},
+			),
+			want: map[roudo.Date][]roudo.Roudo{
+				"2024-03-01": {{StartAt: ptr(at(1, 9, 0)), EndAt: ptr(at(1, 12, 0))}},
+			},
+			wantState: roudo.RoudoStateOff,
+		},
+		{
+			name: "労働を終了した後の入力で、同じ日の新しい労働を始める",
+			steps: concat(
+				events(at(1, 9, 0), at(1, 12, 0)),
+				events(at(1, 17, 0), at(1, 18, 0)),
+			),
+			want: map[roudo.Date][]roudo.Roudo{
+				"2024-03-01": {
+					{StartAt: ptr(at(1, 9, 0)), EndAt: ptr(at(1, 12, 0))},
+					{StartAt: ptr(at(1, 17, 0))},
+				},
+			},
+			wantState: roudo.RoudoStateWorking,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSimulator(t, tt.steps[0].At)
+			if err := s.Replay(tt.steps...); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Verify(tt.want); err != nil {
+				t.Error(err)
+			}
+			state, err := s.Repo.GetCurrentState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state != tt.wantState {
+				t.Errorf("current_state = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestSimulatorReportsMissingSession(t *testing.T) {
+	s := newTestSimulator(t, at(1, 9, 0))
+	// 労働記録がないのに休憩中になっている壊れた状態
+	if err := s.Repo.SaveCurrentState(roudo.RoudoStateBreaking); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Repo.SaveLastEventAt(roudo.NewRoudoTime(at(1, 9, 0), 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Replay(Tick(at(1, 13, 1))); err == nil {
+		t.Fatal("終了する労働がないのにエラーになりませんでした")
+	}
+	state, err := s.Repo.GetCurrentState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 状態だけを労働外にして不整合を隠さない
+	if state != roudo.RoudoStateBreaking {
+		t.Errorf("current_state = %s, want %s", state, roudo.RoudoStateBreaking)
+	}
+}
+
+func TestSimulatorBackdatedManualActions(t *testing.T) {
+	t.Run("遡って労働を開始しても、直後に遡った時刻から休憩にしない", func(t *testing.T) {
+		s := newTestSimulator(t, at(1, 11, 0))
+		if err := s.Reporter.StartWorking(at(1, 9, 0)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Replay(Tick(at(1, 11, 1))); err != nil {
+			t.Fatal(err)
+		}
+		want := map[roudo.Date][]roudo.Roudo{
+			"2024-03-01": {{StartAt: ptr(at(1, 9, 0))}},
+		}
+		if err := s.Verify(want); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("遡って休憩を終えても、直後に遡った時刻から休憩にしない", func(t *testing.T) {
+		s := newTestSimulator(t, at(1, 9, 0))
+		if err := s.Replay(events(at(1, 9, 0), at(1, 10, 0))...); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.PollUntil(at(1, 11, 0)); err != nil {
+			t.Fatal(err)
+		}
+		s.Clock.Set(at(1, 11, 0))
+		if err := s.Reporter.FinishBreaking(at(1, 10, 10)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Replay(Tick(at(1, 11, 1))); err != nil {
+			t.Fatal(err)
+		}
+		want := map[roudo.Date][]roudo.Roudo{
+			"2024-03-01": {{
+				StartAt: ptr(at(1, 9, 0)),
+				Breaks:  []roudo.Break{{StartAt: at(1, 10, 0), EndAt: ptr(at(1, 10, 10))}},
+			}},
+		}
+		if err := s.Verify(want); err != nil {
+			t.Error(err)
+		}
+		state, err := s.Repo.GetCurrentState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state != roudo.RoudoStateWorking {
+			t.Errorf("current_state = %s, want %s", state, roudo.RoudoStateWorking)
+		}
+	})
+}
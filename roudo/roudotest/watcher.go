@@ -0,0 +1,49 @@
+package roudotest
+
+import (
+	"roudo/roudo_event"
+	"sync"
+)
+
+// FakeWatcher は Send で渡したイベントをそのまま RoudoManager に送る roudo_event.Watcher
+type FakeWatcher struct {
+	mu       sync.Mutex
+	onEvent  func(e roudo_event.Event)
+	watching chan struct{}
+	stopped  chan struct{}
+	stop     sync.Once
+}
+
+func NewFakeWatcher() *FakeWatcher {
+	return &FakeWatcher{
+		watching: make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+func (w *FakeWatcher) Name() string {
+	return "FakeWatcher"
+}
+
+func (w *FakeWatcher) Watch(onEvent func(e roudo_event.Event)) error {
+	w.mu.Lock()
+	w.onEvent = onEvent
+	w.mu.Unlock()
+	close(w.watching)
+	<-w.stopped
+	return nil
+}
+
+func (w *FakeWatcher) Stop() error {
+	w.stop.Do(func() { close(w.stopped) })
+	return nil
+}
+
+// Send は Watch が呼ばれるのを待ってから e を送り、受け取った側の処理が終わるまで待つ
+func (w *FakeWatcher) Send(e roudo_event.Event) {
+	<-w.watching
+	w.mu.Lock()
+	onEvent := w.onEvent
+	w.mu.Unlock()
+	onEvent(e)
+}
@@ -0,0 +1,73 @@
+package roudo
+
+import "time"
+
+type RoudoStatus struct {
+	State RoudoState
+	Date  Date
+	// 進行中の労働の開始時刻。労働中でなければ nil
+	SessionStartAt *time.Time
+	// 進行中の休憩の開始時刻。休憩中でなければ nil
+	BreakStartAt *time.Time
+	LastEventAt  *time.Time
+	// Date の労働時間と休憩時間の合計
+	WorkingTime time.Duration
+	BreakTime   time.Duration
+	// 自動で休憩に入るまでの時間。労働中でなければ nil
+	UntilAutoBreak *time.Duration
+	// 自動で労働終了とみなされるまでの時間。休憩中でなければ nil
+	UntilAutoFinish *time.Duration
+}
+
+func (r *roudoReport) GetStatus(now time.Time) (*RoudoStatus, error) {
+	// 読み取りだけなので書き換え元はない
+	r.lock("")
+	defer r.unlock()
+
+	s, err := r.repo.GetCurrentState()
+	if err != nil {
+		return nil, err
+	}
+	lastEventAt, err := r.repo.GetLastEventAt()
+	if err != nil {
+		return nil, err
+	}
+
+	st := &RoudoStatus{
+		State: s,
+		Date:  NewRoudoTime(now, r.shiftDuration).ShiftedDate(),
+	}
+	if lastEventAt != nil {
+		st.LastEventAt = lastEventAt.Time()
+	}
+
+	rs, err := r.repo.GetRoudoReport(st.Date)
+	if err != nil {
+		return nil, err
+	}
+	for _, ro := range rs {
+		st.WorkingTime += ro.WorkingTimeAt(now)
+		st.BreakTime += ro.BreakTimeAt(now)
+	}
+
+	if s != RoudoStateOff && len(rs) != 0 && rs[len(rs)-1].EndAt == nil {
+		current := rs[len(rs)-1]
+		st.SessionStartAt = current.StartAt
+		if len(current.Breaks) != 0 && current.Breaks[len(current.Breaks)-1].EndAt == nil {
+			st.BreakStartAt = &current.Breaks[len(current.Breaks)-1].StartAt
+		}
+	}
+
+	if lastEventAt != nil {
+		switch s {
+		case RoudoStateWorking:
+			d := max(lastEventAt.Time().Add(r.startBreakInterval).Sub(now), 0)
+			st.UntilAutoBreak = &d
+		case RoudoStateBreaking:
+			d := max(lastEventAt.Time().Add(r.finishWorkingInterval).Sub(now), 0)
+			st.UntilAutoFinish = &d
+		}
+	}
+
+	return st, nil
+}
@@ -0,0 +1,117 @@
+package roudo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetStatus(t *testing.T) {
+	at := func(hour, min int) *time.Time {
+		t := time.Date(2024, 3, 1, hour, min, 0, 0, time.Local)
+		return &t
+	}
+	duration := func(d time.Duration) *time.Duration {
+		return &d
+	}
+	date := Date("2024-03-01")
+
+	tests := []struct {
+		name        string
+		stored      []Roudo
+		state       RoudoState
+		lastEventAt *time.Time
+		now         time.Time
+		want        RoudoStatus
+	}{
+		{
+			name:        "労働外",
+			stored:      []Roudo{{StartAt: at(9, 0), EndAt: at(12, 0), Breaks: []Break{{StartAt: *at(10, 0), EndAt: at(10, 30)}}}},
+			state:       RoudoStateOff,
+			lastEventAt: at(12, 0),
+			now:         *at(14, 0),
+			want: RoudoStatus{
+				State:       RoudoStateOff,
+				Date:        date,
+				LastEventAt: at(12, 0),
+				WorkingTime: 2*time.Hour + 30*time.Minute,
+				BreakTime:   30 * time.Minute,
+			},
+		},
+		{
+			name: "労働中は終了した労働と進行中の労働を合計する",
+			stored: []Roudo{
+				{StartAt: at(9, 0), EndAt: at(12, 0)},
+				{StartAt: at(13, 0), Breaks: []Break{{StartAt: *at(14, 0), EndAt: at(14, 15)}}},
+			},
+			state:       RoudoStateWorking,
+			lastEventAt: at(14, 50),
+			now:         *at(15, 0),
+			want: RoudoStatus{
+				State:          RoudoStateWorking,
+				Date:           date,
+				SessionStartAt: at(13, 0),
+				LastEventAt:    at(14, 50),
+				WorkingTime:    4*time.Hour + 45*time.Minute,
+				BreakTime:      15 * time.Minute,
+				// 最終イベントから start_break_interval (35m) 後に休憩になる
+				UntilAutoBreak: duration(25 * time.Minute),
+			},
+		},
+		{
+			name:        "休憩になるはずの時刻を過ぎていれば0",
+			stored:      []Roudo{{StartAt: at(9, 0)}},
+			state:       RoudoStateWorking,
+			lastEventAt: at(9, 0),
+			now:         *at(10, 0),
+			want: RoudoStatus{
+				State:          RoudoStateWorking,
+				Date:           date,
+				SessionStartAt: at(9, 0),
+				LastEventAt:    at(9, 0),
+				WorkingTime:    time.Hour,
+				UntilAutoBreak: duration(0),
+			},
+		},
+		{
+			name:        "休憩中は進行中の休憩を now まで数える",
+			stored:      []Roudo{{StartAt: at(9, 0), Breaks: []Break{{StartAt: *at(10, 0), EndAt: at(10, 30)}, {StartAt: *at(15, 0)}}}},
+			state:       RoudoStateBreaking,
+			lastEventAt: at(15, 0),
+			now:         *at(15, 20),
+			want: RoudoStatus{
+				State:          RoudoStateBreaking,
+				Date:           date,
+				SessionStartAt: at(9, 0),
+				BreakStartAt:   at(15, 0),
+				LastEventAt:    at(15, 0),
+				WorkingTime:    5*time.Hour + 30*time.Minute,
+				BreakTime:      50 * time.Minute,
+				// 最終イベントから finish_working_interval (4h) 後に労働終了になる
+				UntilAutoFinish: duration(3*time.Hour + 40*time.Minute),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reporter, repo, _, clock := newTestReporter(t, DefaultConfig(), tt.now)
+			if err := repo.SaveRoudoReport(date, tt.stored); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.SaveCurrentState(tt.state); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.SaveLastEventAt(NewRoudoTime(*tt.lastEventAt, 0)); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := reporter.GetStatus(clock.Now())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("GetStatus = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
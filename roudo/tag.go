@@ -0,0 +1,118 @@
+package roudo
+
+import (
+	"slices"
+	"time"
+)
+
+// Tag は労働の全体または一部の区間に付けるプロジェクトとタスク。EndAt が nil なら労働の終了まで続く
+type Tag struct {
+	Project string     `json:"project"`
+	Task    string     `json:"task,omitempty"`
+	StartAt time.Time  `json:"start_at"`
+	EndAt   *time.Time `json:"end_at,omitempty"`
+}
+
+// UntaggedProject はタグの付いていない労働時間を集計するプロジェクト名
+const UntaggedProject = ""
+
+// ProjectWorkingTimes は休憩を除いた労働時間をプロジェクトごとに返す。タグのない時間は UntaggedProject に集計する
+func (r *Roudo) ProjectWorkingTimes() map[string]time.Duration {
+	if r.StartAt == nil || r.EndAt == nil {
+		return nil
+	}
+
+	intervals := r.workingIntervals()
+	var working time.Duration
+	for _, iv := range intervals {
+		working += iv[1].Sub(iv[0])
+	}
+
+	totals := make(map[string]time.Duration)
+	var tagged time.Duration
+	for _, tag := range r.Tags {
+		end := *r.EndAt
+		if tag.EndAt != nil && tag.EndAt.Before(end) {
+			end = *tag.EndAt
+		}
+		var d time.Duration
+		for _, iv := range intervals {
+			d += overlap(iv[0], iv[1], tag.StartAt, end)
+		}
+		if d > 0 {
+			totals[tag.Project] += d
+			tagged += d
+		}
+	}
+	if untagged := working - tagged; untagged > 0 {
+		totals[UntaggedProject] += untagged
+	}
+	return totals
+}
+
+// ProjectTotal はプロジェクトごとの労働時間の合計
+type ProjectTotal struct {
+	Project     string
+	WorkingTime time.Duration
+}
+
+// CalcProjectTotals は reports の労働時間をプロジェクトごとに合計し、プロジェクト名の順に返す。UntaggedProject は最後に置く
+func CalcProjectTotals(reports []DailyReport) []ProjectTotal {
+	totals := make(map[string]time.Duration)
+	for _, report := range reports {
+		for _, r := range report.Roudos {
+			for project, d := range r.ProjectWorkingTimes() {
+				totals[project] += d
+			}
+		}
+	}
+
+	result := make([]ProjectTotal, 0, len(totals))
+	for project, d := range totals {
+		result = append(result, ProjectTotal{Project: project, WorkingTime: d})
+	}
+	slices.SortFunc(result, func(a, b ProjectTotal) int {
+		switch {
+		case a.Project == b.Project:
+			return 0
+		case a.Project == UntaggedProject:
+			return 1
+		case b.Project == UntaggedProject:
+			return -1
+		case a.Project < b.Project:
+			return -1
+		}
+		return 1
+	})
+	return result
+}
+
+// HasTags は reports にタグの付いた労働があるかを返す
+func HasTags(reports []DailyReport) bool {
+	for _, report := range reports {
+		for _, r := range report.Roudos {
+			if len(r.Tags) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tagAt は現在のタグを at で閉じ、project が空でなければ at から新しいタグを付ける
+func (r *Roudo) tagAt(at time.Time, project, task string) {
+	tags := r.Tags[:0:0]
+	for _, tag := range r.Tags {
+		if tag.EndAt == nil {
+			if !tag.StartAt.Before(at) {
+				continue
+			}
+			tag.EndAt = &at
+		}
+		tags = append(tags, tag)
+	}
+	if project != "" {
+		tags = append(tags, Tag{Project: project, Task: task, StartAt: at})
+	}
+	r.Tags = tags
+}
@@ -0,0 +1,178 @@
+package roudo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 1つの労働がこれより長い場合は警告する
+const longSessionThreshold = 24 * time.Hour
+
+// ValidationIssue は労働記録の不整合1件。Warning でなければ保存を拒否する
+type ValidationIssue struct {
+	Message string
+	Warning bool
+}
+
+type ValidationIssues []ValidationIssue
+
+// Err は警告でない不整合があればそれらをまとめたエラーを返す
+func (is ValidationIssues) Err() error {
+	var msgs []string
+	for _, i := range is {
+		if !i.Warning {
+			msgs = append(msgs, i.Message)
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+func (is ValidationIssues) Warnings() []string {
+	var msgs []string
+	for _, i := range is {
+		if i.Warning {
+			msgs = append(msgs, i.Message)
+		}
+	}
+	return msgs
+}
+
+// Validator は手動で編集・取り込みした労働記録を検証する
+type Validator struct {
+	shiftDuration time.Duration
+}
+
+func NewValidator(shiftDuration time.Duration) *Validator {
+	return &Validator{shiftDuration: shiftDuration}
+}
+
+// ParseTime は HH:mm を date の日付のローカル時刻として解釈する。日付の切り替わりより前の時刻は翌日とみなす
+func (v *Validator) ParseTime(date Date, s string) (time.Time, error) {
+	d, err := date.Time()
+	if err != nil {
+		return time.Time{}, err
+	}
+	hm, err := time.Parse("15:04", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("時刻の形式が不正です ex: 09:15")
+	}
+	t := time.Date(d.Year(), d.Month(), d.Day(), hm.Hour(), hm.Minute(), 0, 0, time.Local)
+	if t.Sub(d) < v.shiftDuration {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// Validate は date の労働記録 rs の不整合を返す
+func (v *Validator) Validate(date Date, rs []Roudo) ValidationIssues {
+	var issues ValidationIssues
+	errorf := func(format string, args ...any) {
+		issues = append(issues, ValidationIssue{Message: fmt.Sprintf(format, args...)})
+	}
+	warnf := func(format string, args ...any) {
+		issues = append(issues, ValidationIssue{Message: fmt.Sprintf(format, args...), Warning: true})
+	}
+
+	var sessions []Roudo
+	for i, r := range rs {
+		if r.StartAt == nil {
+			errorf("%d 番目の労働に開始時刻がありません", i+1)
+			continue
+		}
+		label := sessionLabel(r)
+		if d := NewRoudoTime(r.StartAt.Local(), v.shiftDuration).ShiftedDate(); d != date {
+			errorf("労働 %s の開始が %s ではなく %s になっています", label, date, d)
+		}
+		if r.EndAt != nil {
+			switch {
+			case r.EndAt.Before(*r.StartAt):
+				errorf("労働 %s の終了が開始より前です", label)
+			case r.EndAt.Equal(*r.StartAt):
+				warnf("労働 %s の長さが0です", label)
+			case r.EndAt.Sub(*r.StartAt) > longSessionThreshold:
+				warnf("労働 %s が %s を超えています", label, longSessionThreshold)
+			}
+		}
+		v.validateBreaks(r, errorf)
+		sessions = append(sessions, r)
+	}
+
+	// 開始時刻順に並べて、前の労働が終わる前に次の労働が始まっていないかを見る
+	slices.SortFunc(sessions, func(a, b Roudo) int {
+		return a.StartAt.Compare(*b.StartAt)
+	})
+	for i := 1; i < len(sessions); i++ {
+		prev, cur := sessions[i-1], sessions[i]
+		if prev.EndAt == nil || prev.EndAt.After(*cur.StartAt) {
+			errorf("労働 %s と %s が重なっています", sessionLabel(prev), sessionLabel(cur))
+		}
+	}
+	return issues
+}
+
+func (v *Validator) validateBreaks(r Roudo, errorf func(format string, args ...any)) {
+	label := sessionLabel(r)
+	breaks := slices.Clone(r.Breaks)
+	for i, b := range breaks {
+		bl := timeRangeLabel(&b.StartAt, b.EndAt)
+		if b.EndAt != nil && b.EndAt.Before(b.StartAt) {
+			errorf("休憩 %s の終了が開始より前です", bl)
+		}
+		if b.StartAt.Before(*r.StartAt) {
+			errorf("休憩 %s が労働 %s の開始より前です", bl, label)
+		}
+		if r.EndAt != nil {
+			if b.EndAt == nil {
+				errorf("終了した労働 %s に終了していない休憩 %s があります", label, bl)
+			} else if b.EndAt.After(*r.EndAt) || b.StartAt.After(*r.EndAt) {
+				errorf("休憩 %s が労働 %s の終了より後です", bl, label)
+			}
+		} else if b.EndAt == nil && i != len(breaks)-1 {
+			errorf("終了していない休憩 %s の後に別の休憩があります", bl)
+		}
+	}
+
+	slices.SortFunc(breaks, func(a, b Break) int {
+		return a.StartAt.Compare(b.StartAt)
+	})
+	for i := 1; i < len(breaks); i++ {
+		prev, cur := breaks[i-1], breaks[i]
+		if prev.EndAt == nil || prev.EndAt.After(cur.StartAt) {
+			errorf("休憩 %s と %s が重なっています", timeRangeLabel(&prev.StartAt, prev.EndAt), timeRangeLabel(&cur.StartAt, cur.EndAt))
+		}
+	}
+}
+
+// ValidateChange は before から after への書き換えで新たに生じた不整合を返す。既にあった不整合は警告として返し、古い記録を少しずつ直せるようにする
+func (v *Validator) ValidateChange(date Date, before, after []Roudo) ValidationIssues {
+	existing := v.Validate(date, before)
+	var issues ValidationIssues
+	for _, i := range v.Validate(date, after) {
+		if !i.Warning && slices.ContainsFunc(existing, func(e ValidationIssue) bool { return e.Message == i.Message }) {
+			i.Warning = true
+		}
+		issues = append(issues, i)
+	}
+	return issues
+}
+
+func sessionLabel(r Roudo) string {
+	return timeRangeLabel(r.StartAt, r.EndAt)
+}
+
+func timeRangeLabel(start, end *time.Time) string {
+	return timeLabel(start) + "~" + timeLabel(end)
+}
+
+func timeLabel(t *time.Time) string {
+	if t == nil {
+		return "--:--"
+	}
+	return t.Local().Format("15:04")
+}
@@ -0,0 +1,191 @@
+package roudo
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidatorValidate(t *testing.T) {
+	const shiftDuration = 5 * time.Hour
+	at := func(day, hour, min int) *time.Time {
+		t := time.Date(2024, 3, day, hour, min, 0, 0, time.Local)
+		return &t
+	}
+	session := func(start, end *time.Time, breaks ...Break) Roudo {
+		return Roudo{StartAt: start, EndAt: end, Breaks: breaks}
+	}
+	brk := func(start, end *time.Time) Break {
+		return Break{StartAt: *start, EndAt: end}
+	}
+
+	tests := []struct {
+		name         string
+		rs           []Roudo
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{
+			name: "正常な記録",
+			rs: []Roudo{
+				session(at(1, 9, 0), at(1, 12, 0)),
+				session(at(1, 13, 0), at(1, 18, 0), brk(at(1, 15, 0), at(1, 15, 30))),
+			},
+		},
+		{
+			name: "日付の切り替わりまでの深夜の労働はその日の記録",
+			rs:   []Roudo{session(at(2, 4, 0), at(2, 4, 59))},
+		},
+		{
+			name: "切り替わり後に始まった労働は翌日の記録",
+			rs:   []Roudo{session(at(2, 5, 0), at(2, 6, 0))},
+			wantErrors: []string{
+				"労働 05:00~06:00 の開始が 2024-03-01 ではなく 2024-03-02 になっています",
+			},
+		},
+		{
+			name: "切り替わり前に始まった労働は前日の記録",
+			rs:   []Roudo{session(at(1, 4, 0), at(1, 9, 0))},
+			wantErrors: []string{
+				"労働 04:00~09:00 の開始が 2024-03-01 ではなく 2024-02-29 になっています",
+			},
+		},
+		{
+			name:       "開始時刻がない",
+			rs:         []Roudo{{EndAt: at(1, 18, 0)}},
+			wantErrors: []string{"1 番目の労働に開始時刻がありません"},
+		},
+		{
+			name:       "終了が開始より前",
+			rs:         []Roudo{session(at(1, 18, 0), at(1, 9, 0))},
+			wantErrors: []string{"労働 18:00~09:00 の終了が開始より前です"},
+		},
+		{
+			name:         "長さが0の労働と長すぎる労働は警告",
+			rs:           []Roudo{session(at(1, 9, 0), at(1, 9, 0)), session(at(1, 10, 0), at(2, 11, 0))},
+			wantWarnings: []string{"労働 09:00~09:00 の長さが0です", "労働 10:00~11:00 が 24h0m0s を超えています"},
+		},
+		{
+			name: "並び順に関係なく労働の重なりを見つける",
+			rs: []Roudo{
+				session(at(1, 13, 0), at(1, 18, 0)),
+				session(at(1, 9, 0), at(1, 13, 30)),
+			},
+			wantErrors: []string{"労働 09:00~13:30 と 13:00~18:00 が重なっています"},
+		},
+		{
+			name: "終了していない労働の後の労働は重なり",
+			rs: []Roudo{
+				session(at(1, 9, 0), nil),
+				session(at(1, 13, 0), at(1, 18, 0)),
+			},
+			wantErrors: []string{"労働 09:00~--:-- と 13:00~18:00 が重なっています"},
+		},
+		{
+			name: "休憩が労働の開始より前",
+			rs:   []Roudo{session(at(1, 9, 0), at(1, 18, 0), brk(at(1, 8, 30), at(1, 9, 30)))},
+			wantErrors: []string{
+				"休憩 08:30~09:30 が労働 09:00~18:00 の開始より前です",
+			},
+		},
+		{
+			name: "休憩が労働の終了より後",
+			rs:   []Roudo{session(at(1, 9, 0), at(1, 18, 0), brk(at(1, 17, 30), at(1, 18, 30)), brk(at(1, 19, 0), at(1, 19, 30)))},
+			wantErrors: []string{
+				"休憩 17:30~18:30 が労働 09:00~18:00 の終了より後です",
+				"休憩 19:00~19:30 が労働 09:00~18:00 の終了より後です",
+			},
+		},
+		{
+			name:       "終了した労働に終了していない休憩",
+			rs:         []Roudo{session(at(1, 9, 0), at(1, 18, 0), brk(at(1, 12, 0), nil))},
+			wantErrors: []string{"終了した労働 09:00~18:00 に終了していない休憩 12:00~--:-- があります"},
+		},
+		{
+			name: "終了していない労働の最後の休憩は終了していなくてよい",
+			rs:   []Roudo{session(at(1, 9, 0), nil, brk(at(1, 12, 0), at(1, 13, 0)), brk(at(1, 15, 0), nil))},
+		},
+		{
+			name: "休憩の重なり",
+			rs:   []Roudo{session(at(1, 9, 0), at(1, 18, 0), brk(at(1, 14, 0), at(1, 14, 30)), brk(at(1, 12, 0), at(1, 14, 15)))},
+			wantErrors: []string{
+				"休憩 12:00~14:15 と 14:00~14:30 が重なっています",
+			},
+		},
+		{
+			name:       "休憩の終了が開始より前",
+			rs:         []Roudo{session(at(1, 9, 0), at(1, 18, 0), brk(at(1, 13, 0), at(1, 12, 0)))},
+			wantErrors: []string{"休憩 13:00~12:00 の終了が開始より前です"},
+		},
+	}
+	v := NewValidator(shiftDuration)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := v.Validate("2024-03-01", tt.rs)
+			var errs []string
+			for _, i := range issues {
+				if !i.Warning {
+					errs = append(errs, i.Message)
+				}
+			}
+			if !slices.Equal(errs, tt.wantErrors) {
+				t.Errorf("errors = %q, want %q", errs, tt.wantErrors)
+			}
+			if got := issues.Warnings(); !slices.Equal(got, tt.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", got, tt.wantWarnings)
+			}
+			if (issues.Err() != nil) != (len(tt.wantErrors) != 0) {
+				t.Errorf("Err() = %v", issues.Err())
+			}
+		})
+	}
+}
+
+func TestValidatorValidateChange(t *testing.T) {
+	v := NewValidator(5 * time.Hour)
+	at := func(hour, min int) *time.Time {
+		t := time.Date(2024, 3, 1, hour, min, 0, 0, time.Local)
+		return &t
+	}
+	overlapping := []Roudo{{StartAt: at(9, 0), EndAt: at(13, 30)}, {StartAt: at(13, 0), EndAt: at(18, 0)}}
+
+	// 元からあった重なりは警告にして、他の箇所を直せるようにする
+	edited := []Roudo{
+		{StartAt: at(9, 0), EndAt: at(13, 30), Breaks: []Break{{StartAt: *at(12, 0), EndAt: at(12, 30)}}},
+		{StartAt: at(13, 0), EndAt: at(18, 0)},
+	}
+	if err := v.ValidateChange("2024-03-01", overlapping, edited).Err(); err != nil {
+		t.Errorf("既存の不整合で保存を拒否しました: %v", err)
+	}
+
+	// 新しく生じた不整合は拒否する
+	if err := v.ValidateChange("2024-03-01", nil, overlapping).Err(); err == nil {
+		t.Error("新しい重なりを拒否しませんでした")
+	}
+}
+
+func TestValidatorParseTime(t *testing.T) {
+	v := NewValidator(5 * time.Hour)
+	tests := []struct {
+		s    string
+		want time.Time
+	}{
+		{s: "09:15", want: time.Date(2024, 3, 1, 9, 15, 0, 0, time.Local)},
+		{s: "05:00", want: time.Date(2024, 3, 1, 5, 0, 0, 0, time.Local)},
+		// 日付の切り替わりより前は翌日の時刻
+		{s: "04:59", want: time.Date(2024, 3, 2, 4, 59, 0, 0, time.Local)},
+		{s: "00:00", want: time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := v.ParseTime("2024-03-01", tt.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%s) = %s, want %s", tt.s, got, tt.want)
+		}
+	}
+	if _, err := v.ParseTime("2024-03-01", "9時"); err == nil {
+		t.Error("不正な形式がエラーになりません")
+	}
+}
@@ -0,0 +1,125 @@
+package roudo_daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"roudo/roudo"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Client はデーモンに接続し、roudo.RoudoController と roudo.RoudoEditor として振る舞う
+type Client struct {
+	rpc *rpc.Client
+}
+
+var (
+	_ roudo.RoudoController = (*Client)(nil)
+	_ roudo.RoudoEditor     = (*Client)(nil)
+)
+
+// Dial はデーモンに接続する。デーモンが起動していなければ ErrNotRunning を返す
+func Dial(dir string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", SocketPath(dir), time.Second)
+	if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+		return nil, ErrNotRunning
+	} else if err != nil {
+		return nil, fmt.Errorf("デーモンに接続できませんでした: %w", err)
+	}
+	return &Client{rpc: rpc.NewClientWithCodec(jsonrpc.NewClientCodec(conn))}, nil
+}
+
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
+
+func (c *Client) call(method string, args any, reply any) error {
+	err := c.rpc.Call(serviceName+"."+method, args, reply)
+	var se rpc.ServerError
+	if errors.As(err, &se) {
+		// デーモン側のエラーメッセージをそのまま利用者に見せる。書き換えの競合は呼び出し側が見分けられるようにする
+		if strings.Contains(string(se), roudo.ErrReportChanged.Error()) {
+			return &remoteError{msg: string(se), err: roudo.ErrReportChanged}
+		}
+		return errors.New(string(se))
+	}
+	return err
+}
+
+// remoteError はデーモン側のエラーメッセージを保ったまま、errors.Is で元のエラーと比べられるようにする
+type remoteError struct {
+	msg string
+	err error
+}
+
+func (e *remoteError) Error() string {
+	return e.msg
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.err
+}
+
+func (c *Client) Ping() (*PingReply, error) {
+	var reply PingReply
+	if err := c.call("Ping", struct{}{}, &reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+func (c *Client) StartWorking(at time.Time) error {
+	return c.call("StartWorking", AtArgs{At: at}, &struct{}{})
+}
+
+func (c *Client) FinishWorking(at time.Time) error {
+	return c.call("FinishWorking", AtArgs{At: at}, &struct{}{})
+}
+
+func (c *Client) StartBreaking(at time.Time) error {
+	return c.call("StartBreaking", AtArgs{At: at}, &struct{}{})
+}
+
+func (c *Client) FinishBreaking(at time.Time) error {
+	return c.call("FinishBreaking", AtArgs{At: at}, &struct{}{})
+}
+
+func (c *Client) Tag(at time.Time, project, task string) error {
+	return c.call("Tag", TagArgs{At: at, Project: project, Task: task}, &struct{}{})
+}
+
+func (c *Client) GetStatus(now time.Time) (*roudo.RoudoStatus, error) {
+	var reply roudo.RoudoStatus
+	if err := c.call("GetStatus", StatusArgs{Now: now}, &reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+func (c *Client) ListRoudoReports(from, to roudo.Date) (map[roudo.Date][]roudo.Roudo, error) {
+	var reply ListReply
+	if err := c.call("ListRoudoReports", ListArgs{From: from, To: to}, &reply); err != nil {
+		return nil, err
+	}
+	return reply.Reports, nil
+}
+
+func (c *Client) SaveRoudoReport(date roudo.Date, rs []roudo.Roudo, source roudo.AuditSource) error {
+	return c.call("SaveRoudoReport", SaveArgs{Date: date, Roudos: rs, Source: source}, &struct{}{})
+}
+
+func (c *Client) ReplaceRoudoReport(date roudo.Date, expected, rs []roudo.Roudo, source roudo.AuditSource) error {
+	return c.call("ReplaceRoudoReport", ReplaceArgs{Date: date, Expected: expected, Roudos: rs, Source: source}, &struct{}{})
+}
+
+func (c *Client) Doctor(fix bool) ([]roudo.Finding, error) {
+	var reply DoctorReply
+	if err := c.call("Doctor", DoctorArgs{Fix: fix}, &reply); err != nil {
+		return nil, err
+	}
+	return reply.Findings, nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package roudo_daemon
+
+import "os/exec"
+
+// Detach は cmd を端末から切り離して新しいセッションで起動するようにする
+func Detach(cmd *exec.Cmd) {}
@@ -0,0 +1,13 @@
+//go:build unix
+
+package roudo_daemon
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Detach は cmd を端末から切り離して新しいセッションで起動するようにする
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
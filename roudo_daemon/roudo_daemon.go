@@ -0,0 +1,153 @@
+// Package roudo_daemon は kansi をバックグラウンドで動かし、Unix ドメインソケット越しに JSON-RPC で操作するための仕組みを提供する
+package roudo_daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alexflint/go-filemutex"
+)
+
+const (
+	socketFile = "roudo.sock"
+	pidFile    = "roudo.pid"
+	// lockFile はデーモンが起動している間ロックし続けるファイル
+	lockFile = "daemon.lock"
+	// LogFile はデーモンの標準出力・標準エラー出力の書き込み先
+	LogFile = "daemon.log"
+
+	serviceName = "Roudo"
+)
+
+// ErrNotRunning はデーモンが起動していないことを表す
+var ErrNotRunning = errors.New("デーモンは起動していません")
+
+// ロックが一瞬だけ取られているときに待つ回数と間隔
+const (
+	lockRetries       = 10
+	lockRetryInterval = 20 * time.Millisecond
+)
+
+func SocketPath(dir string) string {
+	return filepath.Join(dir, socketFile)
+}
+
+func PIDPath(dir string) string {
+	return filepath.Join(dir, pidFile)
+}
+
+func lockPath(dir string) string {
+	return filepath.Join(dir, lockFile)
+}
+
+// ReadPID はデーモンが起動していればその PID を返す。そうでなければ ErrNotRunning を返す。
+// 異常終了したデーモンの PID は別のプロセスに再利用されていることがあるので、生死はロックを持っているかで判断する
+func ReadPID(dir string) (int, error) {
+	if _, err := os.Stat(lockPath(dir)); errors.Is(err, os.ErrNotExist) {
+		return 0, ErrNotRunning
+	}
+	lock, err := filemutex.New(lockPath(dir))
+	if err != nil {
+		return 0, fmt.Errorf("デーモンのロックファイルを開けませんでした: %w", err)
+	}
+	defer lock.Close()
+	if err := lock.TryLock(); err == nil {
+		return 0, ErrNotRunning
+	} else if !errors.Is(err, filemutex.AlreadyLocked) {
+		return 0, err
+	}
+
+	// ロックを取った直後のデーモンはまだ PID ファイルを書いていないことがある
+	var bs []byte
+	for i := 0; ; i++ {
+		bs, err = os.ReadFile(PIDPath(dir))
+		if !errors.Is(err, os.ErrNotExist) || i >= lockRetries {
+			break
+		}
+		time.Sleep(lockRetryInterval)
+	}
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(bs)))
+	if err != nil {
+		return 0, fmt.Errorf("PID ファイルの形式が不正です: %s: %w", PIDPath(dir), err)
+	}
+	return pid, nil
+}
+
+// writePID はデーモンのロックを取ってから PID ファイルを書き、デーモンが終了するまで持ち続けるロックを返す。
+// 同時に起動した他のデーモンはロックを取れずにエラーになる。ロックファイルは消さないので、消した直後に別のデーモンが開き直す競合も起きない
+func writePID(dir string) (*filemutex.FileMutex, error) {
+	lock, err := filemutex.New(lockPath(dir))
+	if err != nil {
+		return nil, fmt.Errorf("デーモンのロックファイルを開けませんでした: %w", err)
+	}
+	// ReadPID も確認のために一瞬だけロックを取るので、少し待ってから取り直す
+	for i := 0; ; i++ {
+		err = lock.TryLock()
+		if !errors.Is(err, filemutex.AlreadyLocked) || i >= lockRetries {
+			break
+		}
+		time.Sleep(lockRetryInterval)
+	}
+	if err != nil {
+		lock.Close()
+		if errors.Is(err, filemutex.AlreadyLocked) {
+			if pid, err := ReadPID(dir); err == nil {
+				return nil, fmt.Errorf("デーモンは既に起動しています (pid %d)", pid)
+			}
+			return nil, errors.New("デーモンは既に起動しています")
+		}
+		return nil, err
+	}
+
+	// ロックを持っていれば、残っている PID ファイルは異常終了したデーモンのもの
+	if err := os.WriteFile(PIDPath(dir), []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	return lock, nil
+}
+
+// releasePID は PID ファイルを消してからロックを手放す
+func releasePID(dir string, lock *filemutex.FileMutex) error {
+	err := os.Remove(PIDPath(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	return errors.Join(err, lock.Close())
+}
+
+// Terminate はデーモンに SIGTERM を送り、timeout までに終了するのを待つ
+func Terminate(dir string, timeout time.Duration) (int, error) {
+	pid, err := ReadPID(dir)
+	if err != nil {
+		return 0, err
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return 0, err
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		return 0, fmt.Errorf("デーモンを停止できませんでした (pid %d): %w", pid, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, err := ReadPID(dir); errors.Is(err, ErrNotRunning) {
+			break
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("デーモンが %s 以内に終了しませんでした (pid %d)", timeout, pid)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return pid, nil
+}
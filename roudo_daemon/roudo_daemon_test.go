@@ -0,0 +1,100 @@
+package roudo_daemon
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexflint/go-filemutex"
+)
+
+func TestWritePID(t *testing.T) {
+	dir := t.TempDir()
+
+	// 同時に起動しても1つしか PID ファイルを書けない
+	var (
+		mu    sync.Mutex
+		locks []*filemutex.FileMutex
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := writePID(dir)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			locks = append(locks, lock)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(locks) != 1 {
+		t.Fatalf("%d 個のデーモンが起動しました", len(locks))
+	}
+	if pid, err := ReadPID(dir); err != nil || pid != os.Getpid() {
+		t.Errorf("ReadPID = %d, %v, want %d", pid, err, os.Getpid())
+	}
+	if _, err := writePID(dir); err == nil {
+		t.Error("起動中のデーモンがあるのに PID ファイルを書きました")
+	}
+
+	if err := releasePID(dir, locks[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadPID(dir); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("終了した後の ReadPID = %v, want %v", err, ErrNotRunning)
+	}
+
+	// 異常終了したデーモンの PID ファイルが残っていても起動できる
+	if err := os.WriteFile(PIDPath(dir), []byte("999999999\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := writePID(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer releasePID(dir, lock)
+	if pid, err := ReadPID(dir); err != nil || pid != os.Getpid() {
+		t.Errorf("ReadPID = %d, %v, want %d", pid, err, os.Getpid())
+	}
+}
+
+func TestReadPIDWithoutLock(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ReadPID(dir); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("ロックファイルがないときの ReadPID = %v, want %v", err, ErrNotRunning)
+	}
+
+	// 異常終了したデーモンの PID が生きている別のプロセスに再利用されていても、ロックを持っていなければ起動していない
+	lock, err := writePID(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(PIDPath(dir), []byte(strconv.Itoa(os.Getppid())+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadPID(dir); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("ReadPID = %v, want %v", err, ErrNotRunning)
+	}
+	if _, err := Terminate(dir, time.Second); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Terminate = %v, want %v", err, ErrNotRunning)
+	}
+
+	lock, err = writePID(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer releasePID(dir, lock)
+	if pid, err := ReadPID(dir); err != nil || pid != os.Getpid() {
+		t.Errorf("ReadPID = %d, %v, want %d", pid, err, os.Getpid())
+	}
+}
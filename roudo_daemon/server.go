@@ -0,0 +1,198 @@
+package roudo_daemon
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"roudo/roudo"
+	"sync"
+	"time"
+
+	"github.com/alexflint/go-filemutex"
+)
+
+// Server は PID ファイルとソケットを持ち、RoudoController と RoudoEditor への操作を受け付ける
+type Server struct {
+	dir      string
+	lock     *filemutex.FileMutex
+	listener net.Listener
+	logger   *slog.Logger
+	wg       sync.WaitGroup
+}
+
+// Listen は PID ファイルを作ってソケットで待ち受けを始める。他のデーモンが起動中であればエラーを返す
+func Listen(dir string, reporter roudo.RoudoReporter, logger *slog.Logger) (*Server, error) {
+	lock, err := writePID(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// 前回異常終了したときのソケットが残っていれば消す
+	if err := os.Remove(SocketPath(dir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		releasePID(dir, lock)
+		return nil, err
+	}
+	l, err := net.Listen("unix", SocketPath(dir))
+	if err != nil {
+		releasePID(dir, lock)
+		return nil, fmt.Errorf("ソケットを開けませんでした: %w", err)
+	}
+
+	rs := rpc.NewServer()
+	if err := rs.RegisterName(serviceName, &service{reporter: reporter, pid: os.Getpid(), startedAt: time.Now()}); err != nil {
+		l.Close()
+		releasePID(dir, lock)
+		return nil, err
+	}
+
+	s := &Server{dir: dir, lock: lock, listener: l, logger: logger}
+	s.wg.Add(1)
+	go s.serve(rs)
+	logger.Info("start daemon", slog.String("socket", SocketPath(dir)))
+	return s, nil
+}
+
+func (s *Server) serve(rs *rpc.Server) {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		} else if err != nil {
+			s.logger.Error("failed to accept", slog.String("error", err.Error()))
+			continue
+		}
+		go rs.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// Close は待ち受けを止め、ソケットと PID ファイルを消す
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	os.Remove(SocketPath(s.dir))
+	err = errors.Join(err, releasePID(s.dir, s.lock))
+	s.logger.Info("stop daemon")
+	return err
+}
+
+// service は net/rpc に公開するメソッドを持つ
+type service struct {
+	reporter  roudo.RoudoReporter
+	pid       int
+	startedAt time.Time
+}
+
+type AtArgs struct {
+	At time.Time
+}
+
+type TagArgs struct {
+	At      time.Time
+	Project string
+	Task    string
+}
+
+type StatusArgs struct {
+	Now time.Time
+}
+
+type ListArgs struct {
+	From roudo.Date
+	To   roudo.Date
+}
+
+type ListReply struct {
+	Reports map[roudo.Date][]roudo.Roudo
+}
+
+type SaveArgs struct {
+	Date   roudo.Date
+	Roudos []roudo.Roudo
+	Source roudo.AuditSource
+}
+
+type ReplaceArgs struct {
+	Date     roudo.Date
+	Expected []roudo.Roudo
+	Roudos   []roudo.Roudo
+	Source   roudo.AuditSource
+}
+
+type DoctorArgs struct {
+	Fix bool
+}
+
+type DoctorReply struct {
+	Findings []roudo.Finding
+}
+
+// PingReply はデーモン自身の情報
+type PingReply struct {
+	PID       int
+	StartedAt time.Time
+}
+
+func (s *service) Ping(_ struct{}, reply *PingReply) error {
+	*reply = PingReply{PID: s.pid, StartedAt: s.startedAt}
+	return nil
+}
+
+func (s *service) StartWorking(args AtArgs, _ *struct{}) error {
+	return s.reporter.StartWorking(args.At)
+}
+
+func (s *service) FinishWorking(args AtArgs, _ *struct{}) error {
+	return s.reporter.FinishWorking(args.At)
+}
+
+func (s *service) StartBreaking(args AtArgs, _ *struct{}) error {
+	return s.reporter.StartBreaking(args.At)
+}
+
+func (s *service) FinishBreaking(args AtArgs, _ *struct{}) error {
+	return s.reporter.FinishBreaking(args.At)
+}
+
+func (s *service) Tag(args TagArgs, _ *struct{}) error {
+	return s.reporter.Tag(args.At, args.Project, args.Task)
+}
+
+func (s *service) GetStatus(args StatusArgs, reply *roudo.RoudoStatus) error {
+	st, err := s.reporter.GetStatus(args.Now)
+	if err != nil {
+		return err
+	}
+	*reply = *st
+	return nil
+}
+
+func (s *service) ListRoudoReports(args ListArgs, reply *ListReply) error {
+	reports, err := s.reporter.ListRoudoReports(args.From, args.To)
+	if err != nil {
+		return err
+	}
+	*reply = ListReply{Reports: reports}
+	return nil
+}
+
+func (s *service) SaveRoudoReport(args SaveArgs, _ *struct{}) error {
+	return s.reporter.SaveRoudoReport(args.Date, args.Roudos, args.Source)
+}
+
+func (s *service) ReplaceRoudoReport(args ReplaceArgs, _ *struct{}) error {
+	return s.reporter.ReplaceRoudoReport(args.Date, args.Expected, args.Roudos, args.Source)
+}
+
+func (s *service) Doctor(args DoctorArgs, reply *DoctorReply) error {
+	findings, err := s.reporter.Doctor(args.Fix)
+	if err != nil {
+		return err
+	}
+	*reply = DoctorReply{Findings: findings}
+	return nil
+}
@@ -0,0 +1,91 @@
+package roudo_daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"roudo/roudo"
+	"roudo/roudo/roudotest"
+	"testing"
+	"time"
+)
+
+func TestClientEditsThroughDaemon(t *testing.T) {
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	clock := roudotest.NewFakeClock(time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local))
+	reporter := roudo.NewRoudoReporter(roudo.NewMemoryRoudoReportRepository(), roudo.NewMemoryAuditLog(), logger, &roudo.NopNotificator{}, roudo.NewProcessMutex(), clock, roudo.DefaultConfig())
+
+	server, err := Listen(dir, reporter, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	client, err := Dial(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	at := func(h, m int) *time.Time {
+		t := time.Date(2024, 3, 1, h, m, 0, 0, time.Local)
+		return &t
+	}
+	date := roudo.Date("2024-03-01")
+	saved := []roudo.Roudo{{
+		StartAt: at(9, 0),
+		EndAt:   at(18, 0),
+		Breaks:  []roudo.Break{{StartAt: *at(12, 0), EndAt: at(13, 0)}},
+		Tags:    []roudo.Tag{{StartAt: *at(9, 0), Project: "roudo"}},
+	}}
+	if err := client.SaveRoudoReport(date, saved, roudo.AuditSourceImport); err != nil {
+		t.Fatal(err)
+	}
+
+	reports, err := client.ListRoudoReports("2024-02-29", "2024-03-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || !sameJSON(t, reports[date], saved) {
+		t.Errorf("ListRoudoReports = %+v, want %+v", reports, saved)
+	}
+
+	edited := []roudo.Roudo{{StartAt: at(9, 0), EndAt: at(17, 0)}}
+	// 読み込んだ後に書き換えられていれば、デーモン越しでも ErrReportChanged として見分けられる
+	if err := client.ReplaceRoudoReport(date, nil, edited, roudo.AuditSourceTUI); !errors.Is(err, roudo.ErrReportChanged) {
+		t.Errorf("古い記録を元にした ReplaceRoudoReport = %v, want %v", err, roudo.ErrReportChanged)
+	}
+	if err := client.ReplaceRoudoReport(date, reports[date], edited, roudo.AuditSourceTUI); err != nil {
+		t.Fatal(err)
+	}
+	reports, err = client.ListRoudoReports(date, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameJSON(t, reports[date], edited) {
+		t.Errorf("ReplaceRoudoReport 後の記録 = %+v, want %+v", reports[date], edited)
+	}
+
+	findings, err := client.Doctor(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Doctor = %+v, want no findings", findings)
+	}
+}
+
+// sameJSON はデーモンを経由して時刻の Location が変わっても、同じ記録であれば true を返す
+func sameJSON(t *testing.T, got, want []roudo.Roudo) bool {
+	t.Helper()
+	g, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(g) == string(w)
+}
@@ -0,0 +1,63 @@
+package roudo_event
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ActivityContext は作業中の内容を推定するための情報
+type ActivityContext struct {
+	// アクティブなウィンドウのタイトル
+	WindowTitle string
+	// アクティブなウィンドウで前面にいるプロセスのカレントディレクトリ
+	WorkingDir string
+	// WorkingDir を含む git リポジトリのルート
+	GitRoot string
+}
+
+const (
+	metaWindowTitle = "window_title"
+	metaWorkingDir  = "working_dir"
+	metaGitRoot     = "git_root"
+)
+
+// NewContextEvent は ctx を Meta に持つ KindContext のイベントを返す
+func NewContextEvent(source string, at time.Time, ctx ActivityContext) Event {
+	return Event{
+		Source: source,
+		Kind:   KindContext,
+		At:     at,
+		Meta: map[string]string{
+			metaWindowTitle: ctx.WindowTitle,
+			metaWorkingDir:  ctx.WorkingDir,
+			metaGitRoot:     ctx.GitRoot,
+		},
+	}
+}
+
+// ActivityContext は KindContext のイベントの Meta から ActivityContext を取り出す
+func (e Event) ActivityContext() ActivityContext {
+	return ActivityContext{
+		WindowTitle: e.Meta[metaWindowTitle],
+		WorkingDir:  e.Meta[metaWorkingDir],
+		GitRoot:     e.Meta[metaGitRoot],
+	}
+}
+
+// FindGitRoot は dir から親ディレクトリを辿り、.git のあるディレクトリを返す。見つからなければ空文字を返す
+func FindGitRoot(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,189 @@
+package roudo_event
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultContextInterval はアクティブなウィンドウを問い合わせる間隔
+const DefaultContextInterval = 10 * time.Second
+
+var (
+	xpropWindowIDPattern = regexp.MustCompile(`window id # (0x[0-9a-fA-F]+)`)
+	xpropStringPattern   = regexp.MustCompile(`= (".*")`)
+	xpropCardinalPattern = regexp.MustCompile(`= (\d+)`)
+)
+
+// X11ContextWatcher は xprop で _NET_ACTIVE_WINDOW のタイトルと PID を取得し、
+// そのウィンドウの子孫のうち最も新しいプロセスのカレントディレクトリを作業ディレクトリとする
+type X11ContextWatcher struct {
+	logger   *slog.Logger
+	xprop    string
+	procDir  string
+	interval time.Duration
+	stopper  stopper
+}
+
+func NewX11ContextWatcher(logger *slog.Logger, interval time.Duration) (*X11ContextWatcher, error) {
+	if os.Getenv("DISPLAY") == "" {
+		return nil, errors.New("DISPLAY が設定されていません")
+	}
+	xprop, err := exec.LookPath("xprop")
+	if err != nil {
+		return nil, err
+	}
+	return &X11ContextWatcher{
+		logger:   logger,
+		xprop:    xprop,
+		procDir:  "/proc",
+		interval: interval,
+	}, nil
+}
+
+func (w *X11ContextWatcher) Name() string {
+	return "X11ContextWatcher"
+}
+
+// Watch はアクティブなウィンドウや作業ディレクトリが切り替わるたびに KindContext のイベントを送る
+func (w *X11ContextWatcher) Watch(onEvent func(e Event)) error {
+	var last ActivityContext
+	for {
+		ctx, err := w.current()
+		if err != nil {
+			w.logger.Debug("failed to get activity context", slog.String("error", err.Error()))
+		} else if ctx != last {
+			last = ctx
+			onEvent(NewContextEvent(w.Name(), time.Now(), ctx))
+		}
+		if !w.stopper.sleep(w.interval) {
+			return nil
+		}
+	}
+}
+
+func (w *X11ContextWatcher) Stop() error {
+	w.stopper.stop()
+	return nil
+}
+
+func (w *X11ContextWatcher) current() (ActivityContext, error) {
+	out, err := exec.Command(w.xprop, "-root", "_NET_ACTIVE_WINDOW").Output()
+	if err != nil {
+		return ActivityContext{}, err
+	}
+	id, ok := parseActiveWindowID(out)
+	if !ok {
+		return ActivityContext{}, errors.New("アクティブなウィンドウがありません")
+	}
+
+	out, err = exec.Command(w.xprop, "-id", id, "_NET_WM_NAME", "_NET_WM_PID").Output()
+	if err != nil {
+		return ActivityContext{}, err
+	}
+	title, pid := parseWindowProps(out)
+	ctx := ActivityContext{WindowTitle: title, WorkingDir: w.foregroundDir(pid)}
+	ctx.GitRoot = FindGitRoot(ctx.WorkingDir)
+	return ctx, nil
+}
+
+// parseActiveWindowID は xprop -root _NET_ACTIVE_WINDOW の出力からウィンドウ ID を取り出す
+func parseActiveWindowID(out []byte) (string, bool) {
+	m := xpropWindowIDPattern.FindSubmatch(out)
+	if m == nil {
+		return "", false
+	}
+	return string(m[1]), true
+}
+
+// parseWindowProps は xprop -id ID _NET_WM_NAME _NET_WM_PID の出力からタイトルと PID を取り出す。取れなかったものは空文字や0になる
+func parseWindowProps(out []byte) (string, int) {
+	var (
+		title string
+		pid   int
+	)
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, "_NET_WM_NAME"):
+			if m := xpropStringPattern.FindStringSubmatch(line); m != nil {
+				// xprop はタイトル中の " や \ をエスケープして出力する
+				if t, err := strconv.Unquote(m[1]); err == nil {
+					title = t
+				} else {
+					title = strings.Trim(m[1], `"`)
+				}
+			}
+		case strings.HasPrefix(line, "_NET_WM_PID"):
+			if m := xpropCardinalPattern.FindStringSubmatch(line); m != nil {
+				pid, _ = strconv.Atoi(m[1])
+			}
+		}
+	}
+	return title, pid
+}
+
+// foregroundDir は pid の子孫のうち最後に起動したプロセスのカレントディレクトリを返す。
+// 端末エミュレータであれば、シェルやその上で動いているエディタのディレクトリになる
+func (w *X11ContextWatcher) foregroundDir(pid int) string {
+	if pid <= 0 {
+		return ""
+	}
+	children := w.childrenByParent()
+	fg := pid
+	for {
+		cs := children[fg]
+		if len(cs) == 0 {
+			break
+		}
+		// PID は基本的に起動順に増えるので、最大のものを最後に起動したプロセスとみなす
+		next := cs[0]
+		for _, c := range cs[1:] {
+			next = max(next, c)
+		}
+		fg = next
+	}
+	dir, err := os.Readlink(filepath.Join(w.procDir, strconv.Itoa(fg), "cwd"))
+	if err != nil {
+		return ""
+	}
+	return dir
+}
+
+func (w *X11ContextWatcher) childrenByParent() map[int][]int {
+	children := make(map[int][]int)
+	entries, err := os.ReadDir(w.procDir)
+	if err != nil {
+		return children
+	}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join(w.procDir, e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		// comm に空白や括弧が含まれることがあるので、最後の ")" 以降を読む
+		i := strings.LastIndexByte(string(stat), ')')
+		if i < 0 {
+			continue
+		}
+		fields := strings.Fields(string(stat[i+1:]))
+		if len(fields) < 2 {
+			continue
+		}
+		ppid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		children[ppid] = append(children[ppid], pid)
+	}
+	return children
+}
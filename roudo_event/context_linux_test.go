@@ -0,0 +1,124 @@
+package roudo_event
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestParseActiveWindowID(t *testing.T) {
+	tests := []struct {
+		out    string
+		want   string
+		wantOK bool
+	}{
+		{"_NET_ACTIVE_WINDOW(WINDOW): window id # 0x3a00007\n", "0x3a00007", true},
+		{"_NET_ACTIVE_WINDOW(WINDOW): window id # 0x0, 0x0\n", "0x0", true},
+		{"_NET_ACTIVE_WINDOW:  not found.\n", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseActiveWindowID([]byte(tt.out))
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseActiveWindowID(%q) = %q, %v, want %q, %v", tt.out, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseWindowProps(t *testing.T) {
+	tests := []struct {
+		name      string
+		out       string
+		wantTitle string
+		wantPID   int
+	}{
+		{
+			name:      "タイトルと PID",
+			out:       "_NET_WM_NAME(UTF8_STRING) = \"vim main.go - ~/src/roudo\"\n_NET_WM_PID(CARDINAL) = 4242\n",
+			wantTitle: "vim main.go - ~/src/roudo",
+			wantPID:   4242,
+		},
+		{
+			name:      "タイトルに = やエスケープされた引用符を含む",
+			out:       `_NET_WM_NAME(UTF8_STRING) = "a = \"b\" \\ 日本語"` + "\n_NET_WM_PID(CARDINAL) = 7\n",
+			wantTitle: `a = "b" \ 日本語`,
+			wantPID:   7,
+		},
+		{
+			name:      "PID を持たないウィンドウ",
+			out:       "_NET_WM_NAME(UTF8_STRING) = \"Firefox\"\n_NET_WM_PID:  not found.\n",
+			wantTitle: "Firefox",
+		},
+		{
+			name: "どちらもない",
+			out:  "_NET_WM_NAME:  not found.\n_NET_WM_PID:  not found.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, pid := parseWindowProps([]byte(tt.out))
+			if title != tt.wantTitle || pid != tt.wantPID {
+				t.Errorf("parseWindowProps = %q, %d, want %q, %d", title, pid, tt.wantTitle, tt.wantPID)
+			}
+		})
+	}
+}
+
+// writeProc は procDir に pid のプロセスの stat と cwd を作る
+func writeProc(t *testing.T, procDir string, pid, ppid int, comm, cwd string) {
+	t.Helper()
+	dir := filepath.Join(procDir, strconv.Itoa(pid))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	stat := strconv.Itoa(pid) + " (" + comm + ") S " + strconv.Itoa(ppid) + " 1 1 0 -1\n"
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cwd != "" {
+		if err := os.Symlink(cwd, filepath.Join(dir, "cwd")); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestForegroundDir(t *testing.T) {
+	procDir := t.TempDir()
+	// 端末エミュレータ 100 の上でシェルが2つ動いていて、新しい方のシェルでエディタが動いている
+	writeProc(t, procDir, 1, 0, "init", "/")
+	writeProc(t, procDir, 100, 1, "xterm", "/home/me")
+	writeProc(t, procDir, 200, 100, "bash", "/home/me/old")
+	writeProc(t, procDir, 300, 100, "bash", "/home/me/src/roudo")
+	writeProc(t, procDir, 301, 300, "vim) S 1 (x", "/home/me/src/roudo/view")
+	// 子のいない別のウィンドウ
+	writeProc(t, procDir, 400, 1, "firefox", "/home/me/Downloads")
+	// 読めないものは無視する
+	if err := os.MkdirAll(filepath.Join(procDir, "self"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(procDir, "500"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &X11ContextWatcher{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), procDir: procDir}
+	tests := []struct {
+		pid  int
+		want string
+	}{
+		{100, "/home/me/src/roudo/view"},
+		{200, "/home/me/old"},
+		{400, "/home/me/Downloads"},
+		// cwd を読めないプロセス
+		{500, ""},
+		{999, ""},
+		{0, ""},
+	}
+	for _, tt := range tests {
+		if got := w.foregroundDir(tt.pid); got != tt.want {
+			t.Errorf("foregroundDir(%d) = %q, want %q", tt.pid, got, tt.want)
+		}
+	}
+}
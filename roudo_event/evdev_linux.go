@@ -0,0 +1,246 @@
+package roudo_event
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const DefaultEvdevDir = "/dev/input"
+
+// linux/input-event-codes.h
+const (
+	evKey = 0x01
+	evRel = 0x02
+	evAbs = 0x03
+)
+
+// 同じデバイスから連続で届くイベントはこの間隔で間引く
+const evdevThrottleInterval = 1 * time.Second
+
+// inputEvent は linux/input.h の struct input_event
+type inputEvent struct {
+	Time  unix.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+var sizeofInputEvent = binary.Size(inputEvent{})
+
+func parseInputEvent(b []byte) (inputEvent, error) {
+	var ev inputEvent
+	err := binary.Read(bytes.NewReader(b), binary.NativeEndian, &ev)
+	return ev, err
+}
+
+// isActivity はキー押下とポインタ操作のみを活動とみなす。キーリピートや離した時のイベントは無視する
+func (ev inputEvent) isActivity() bool {
+	switch ev.Type {
+	case evKey:
+		return ev.Value == 1
+	case evRel, evAbs:
+		return true
+	}
+	return false
+}
+
+// evdev の BTN_MISC 以上のコードはマウスなどのボタン
+const btnMisc = 0x100
+
+func (ev inputEvent) kind() Kind {
+	if ev.Type == evKey && ev.Code < btnMisc {
+		return KindKey
+	}
+	return KindPointer
+}
+
+// at はカーネルが付けたイベントの時刻を返す
+func (ev inputEvent) at() time.Time {
+	if ev.Time.Sec == 0 && ev.Time.Usec == 0 {
+		return time.Now()
+	}
+	return time.Unix(int64(ev.Time.Sec), int64(ev.Time.Usec)*int64(time.Microsecond))
+}
+
+// EvdevWatcher は dir 以下の event* デバイスからキーボード・ポインタの操作を監視する。
+// dir を inotify で監視し、後から接続されたデバイスも監視対象に加える
+type EvdevWatcher struct {
+	logger *slog.Logger
+	dir    string
+	// テストではパーミッションのないデバイスを再現したり、間引かないようにしたりするために差し替える
+	open     func(path string) (*os.File, error)
+	throttle time.Duration
+
+	mu       sync.Mutex
+	devices  map[string]*os.File
+	inotify  *os.File
+	lastFire time.Time
+	stopper  stopper
+}
+
+func NewEvdevWatcher(logger *slog.Logger, dir string) *EvdevWatcher {
+	return &EvdevWatcher{
+		logger:   logger,
+		dir:      dir,
+		open:     os.Open,
+		throttle: evdevThrottleInterval,
+		devices:  make(map[string]*os.File),
+	}
+}
+
+func (w *EvdevWatcher) Name() string {
+	return "EvdevWatcher"
+}
+
+func (w *EvdevWatcher) Watch(onEvent func(e Event)) error {
+	// Stop で Close した時に Read から抜けられるよう、ノンブロッキングにしてランタイムのポーラーに任せる
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return err
+	}
+	inotify := os.NewFile(uintptr(fd), "inotify")
+	defer inotify.Close()
+
+	// udev がパーミッションを設定するまで開けないことがあるので IN_ATTRIB も監視する
+	if _, err := unix.InotifyAddWatch(fd, w.dir, unix.IN_CREATE|unix.IN_ATTRIB); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	w.inotify = inotify
+	w.mu.Unlock()
+	select {
+	case <-w.stopper.done():
+		return nil
+	default:
+	}
+
+	paths, err := filepath.Glob(filepath.Join(w.dir, "event*"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		w.openDevice(path, onEvent)
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := inotify.Read(buf)
+		if errors.Is(err, os.ErrClosed) {
+			return nil
+		} else if err != nil {
+			select {
+			case <-w.stopper.done():
+				return nil
+			default:
+			}
+			return err
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+int(ev.Len)]
+			name := strings.TrimRight(string(nameBytes), "\x00")
+			offset += unix.SizeofInotifyEvent + int(ev.Len)
+
+			if strings.HasPrefix(name, "event") {
+				w.openDevice(filepath.Join(w.dir, name), onEvent)
+			}
+		}
+	}
+}
+
+func (w *EvdevWatcher) openDevice(path string, onEvent func(e Event)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.devices[path]; ok {
+		return
+	}
+	f, err := w.open(path)
+	if err != nil {
+		w.logger.Debug("skip input device", slog.String("path", path), slog.String("err", err.Error()))
+		return
+	}
+	w.devices[path] = f
+	w.logger.Debug("watch input device", slog.String("path", path))
+
+	go func() {
+		defer func() {
+			f.Close()
+			w.mu.Lock()
+			delete(w.devices, path)
+			w.mu.Unlock()
+		}()
+		if err := w.readDevice(path, f, onEvent); err != nil {
+			w.logger.Debug("stop watching input device", slog.String("path", path), slog.String("err", err.Error()))
+		}
+	}()
+}
+
+func (w *EvdevWatcher) readDevice(path string, r io.Reader, onEvent func(e Event)) error {
+	buf := make([]byte, sizeofInputEvent)
+	filled := 0
+	for {
+		n, err := r.Read(buf[filled:])
+		filled += n
+		if filled == len(buf) {
+			filled = 0
+			ev, err := parseInputEvent(buf)
+			if err != nil {
+				return err
+			}
+			if ev.isActivity() && w.shouldFire() {
+				onEvent(Event{Source: w.Name(), Kind: ev.kind(), At: ev.at(), Meta: map[string]string{"device": path}})
+			}
+		}
+
+		// 実デバイスは EOF を返さないが、テスト用の通常ファイルは追記を待つ
+		if errors.Is(err, io.EOF) {
+			if !w.stopper.sleep(100 * time.Millisecond) {
+				return nil
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// Stop は inotify と開いている全てのデバイスを閉じ、Watch を終わらせる
+func (w *EvdevWatcher) Stop() error {
+	w.stopper.stop()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.inotify != nil {
+		w.inotify.Close()
+	}
+	for _, f := range w.devices {
+		f.Close()
+	}
+	return nil
+}
+
+func (w *EvdevWatcher) shouldFire() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(w.lastFire) < w.throttle {
+		return false
+	}
+	w.lastFire = now
+	return true
+}
@@ -0,0 +1,160 @@
+package roudo_event
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// writeInputEvents はテスト用のデバイスファイルに struct input_event を追記する
+func writeInputEvents(t *testing.T, path string, evs ...inputEvent) {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, ev := range evs {
+		if err := binary.Write(&buf, binary.NativeEndian, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func keyEvent(at time.Time, code uint16, value int32) inputEvent {
+	return inputEvent{Time: unix.NsecToTimeval(at.UnixNano()), Type: evKey, Code: code, Value: value}
+}
+
+// openAsUser は root で動かしても、読み込みの権限がないファイルを開けないようにする
+func openAsUser(path string) (*os.File, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.Mode().Perm()&0444 == 0 {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrPermission}
+	}
+	return os.Open(path)
+}
+
+func TestEvdevWatcher(t *testing.T) {
+	dir := t.TempDir()
+	keyboard := filepath.Join(dir, "event0")
+	writeInputEvents(t, keyboard)
+	// event で始まらないファイルは監視しない
+	writeInputEvents(t, filepath.Join(dir, "mouse0"))
+
+	w := NewEvdevWatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), dir)
+	w.open = openAsUser
+	w.throttle = 0
+	events := make(chan Event, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Watch(func(e Event) { events <- e })
+	}()
+
+	wait := func(device string, kind Kind, at time.Time) {
+		t.Helper()
+		select {
+		case e := <-events:
+			if e.Meta["device"] != device || e.Kind != kind {
+				t.Errorf("event = %s %s, want %s %s", e.Meta["device"], e.Kind, device, kind)
+			}
+			if !e.At.Equal(at) {
+				t.Errorf("At = %s, want %s", e.At, at)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s のイベントが届きませんでした", device)
+		}
+	}
+	noEvent := func() {
+		t.Helper()
+		select {
+		case e := <-events:
+			t.Errorf("unexpected event: %+v", e)
+		case <-time.After(300 * time.Millisecond):
+		}
+	}
+	// 起動時にあるデバイスを開くまで待つ
+	for i := 0; ; i++ {
+		w.mu.Lock()
+		n := len(w.devices)
+		w.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("デバイスを開きませんでした")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	at := time.Date(2024, 3, 1, 9, 0, 0, 123000, time.Local)
+	// 離した時とリピート、同期のイベントは操作とみなさない
+	writeInputEvents(t, keyboard,
+		keyEvent(at, 30, 0),
+		keyEvent(at, 30, 2),
+		inputEvent{Time: unix.NsecToTimeval(at.UnixNano())},
+	)
+	noEvent()
+	writeInputEvents(t, keyboard, keyEvent(at, 30, 1))
+	wait(keyboard, KindKey, at)
+
+	// 後から接続されたデバイスも監視する
+	mouse := filepath.Join(dir, "event1")
+	writeInputEvents(t, mouse, inputEvent{Time: unix.NsecToTimeval(at.Add(time.Second).UnixNano()), Type: evRel, Value: 3})
+	wait(mouse, KindPointer, at.Add(time.Second))
+
+	// パーミッションが設定されるまでは開けず、設定されたら監視する
+	touchpad := filepath.Join(dir, "event2")
+	if err := os.WriteFile(touchpad, nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	writeInputEvents(t, touchpad, keyEvent(at.Add(2*time.Second), 0x110, 1))
+	noEvent()
+	if err := os.Chmod(touchpad, 0640); err != nil {
+		t.Fatal(err)
+	}
+	wait(touchpad, KindPointer, at.Add(2*time.Second))
+
+	if err := w.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop しても Watch が終わりません")
+	}
+}
+
+func TestEvdevWatcherThrottle(t *testing.T) {
+	dir := t.TempDir()
+	keyboard := filepath.Join(dir, "event0")
+	at := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	writeInputEvents(t, keyboard, keyEvent(at, 30, 1), keyEvent(at, 31, 1), keyEvent(at, 32, 1))
+
+	w := NewEvdevWatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), dir)
+	events := make(chan Event, 16)
+	go w.Watch(func(e Event) { events <- e })
+	defer w.Stop()
+
+	time.Sleep(300 * time.Millisecond)
+	if len(events) != 1 {
+		t.Errorf("events = %d, want 1", len(events))
+	}
+}
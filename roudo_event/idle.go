@@ -0,0 +1,129 @@
+package roudo_event
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultIdleInterval はアイドル時間を問い合わせる間隔
+	DefaultIdleInterval = 5 * time.Second
+	// DefaultIdleThreshold 以上操作がなければアイドルとみなす
+	DefaultIdleThreshold = 1 * time.Minute
+)
+
+// IdleSource は最後にキーボードやポインタが操作されてからの経過時間を返す
+type IdleSource interface {
+	Name() string
+	IdleTime() (time.Duration, error)
+}
+
+// IdleWatcher はシステムのアイドル時間を定期的に問い合わせ、アイドルから操作中に戻った時と、操作が続いている間は
+// threshold ごとにイベントを発火する。マウスだけを操作している時など、他の Watcher が入力を送れない時にも労働中のままにするため
+type IdleWatcher struct {
+	logger    *slog.Logger
+	source    IdleSource
+	interval  time.Duration
+	threshold time.Duration
+
+	idle bool
+	// 最後にイベントを送った時刻
+	sentAt  time.Time
+	stopper stopper
+}
+
+func NewIdleWatcher(logger *slog.Logger, source IdleSource, interval, threshold time.Duration) *IdleWatcher {
+	return &IdleWatcher{
+		logger:    logger,
+		source:    source,
+		interval:  interval,
+		threshold: threshold,
+		// 起動した時は操作中かどうか分からないので、アイドルだったものとして最初の操作を送る
+		idle: true,
+	}
+}
+
+func (w *IdleWatcher) Name() string {
+	return "IdleWatcher(" + w.source.Name() + ")"
+}
+
+func (w *IdleWatcher) Watch(onEvent func(e Event)) error {
+	for {
+		now := time.Now()
+		idleTime, err := w.source.IdleTime()
+		if err != nil {
+			w.logger.Warn("failed to get idle time", slog.String("source", w.source.Name()), slog.String("error", err.Error()))
+		} else if w.observe(now, idleTime) {
+			// アイドル時間が分かるので、最後に操作した時刻をイベントの時刻とする
+			onEvent(Event{
+				Source: w.Name(),
+				Kind:   KindActive,
+				At:     now.Add(-idleTime),
+				Meta:   map[string]string{"idle_source": w.source.Name()},
+			})
+		}
+		if !w.stopper.sleep(w.interval) {
+			return nil
+		}
+	}
+}
+
+func (w *IdleWatcher) Stop() error {
+	w.stopper.stop()
+	return nil
+}
+
+// observe は now に問い合わせたアイドル時間から、イベントを送るべきかを返す。
+// アイドルから操作中に戻った時と、操作中に前回送ってから threshold 以上経った時に送る
+func (w *IdleWatcher) observe(now time.Time, idleTime time.Duration) bool {
+	if idleTime >= w.threshold {
+		if !w.idle {
+			w.logger.Debug("became idle", slog.Duration("idle_time", idleTime))
+		}
+		w.idle = true
+		return false
+	}
+	if !w.idle && now.Sub(w.sentAt) < w.threshold {
+		return false
+	}
+	w.idle = false
+	w.sentAt = now
+	return true
+}
+
+// FakeIdleSource はテスト用に任意のアイドル時間を返す IdleSource
+type FakeIdleSource struct {
+	mu       sync.Mutex
+	idleTime time.Duration
+	err      error
+}
+
+func NewFakeIdleSource() *FakeIdleSource {
+	return &FakeIdleSource{}
+}
+
+func (s *FakeIdleSource) Name() string {
+	return "FakeIdleSource"
+}
+
+func (s *FakeIdleSource) IdleTime() (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.idleTime, s.err
+}
+
+// Set は次に返すアイドル時間を設定する
+func (s *FakeIdleSource) Set(idleTime time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idleTime = idleTime
+	s.err = nil
+}
+
+// SetError は次に返すエラーを設定する
+func (s *FakeIdleSource) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
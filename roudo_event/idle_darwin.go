@@ -0,0 +1,38 @@
+package roudo_event
+
+import (
+	"errors"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var hidIdleTimePattern = regexp.MustCompile(`"HIDIdleTime" = (\d+)`)
+
+func NewIdleSource() (IdleSource, error) {
+	return &IOKitIdleSource{}, nil
+}
+
+// IOKitIdleSource は ioreg で IOHIDSystem の HIDIdleTime を取得する
+type IOKitIdleSource struct{}
+
+func (s *IOKitIdleSource) Name() string {
+	return "IOKit"
+}
+
+func (s *IOKitIdleSource) IdleTime() (time.Duration, error) {
+	out, err := exec.Command("ioreg", "-c", "IOHIDSystem", "-d", "4").Output()
+	if err != nil {
+		return 0, err
+	}
+	m := hidIdleTimePattern.FindSubmatch(out)
+	if m == nil {
+		return 0, errors.New("HIDIdleTime が見つかりません")
+	}
+	ns, err := strconv.ParseInt(string(m[1]), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ns), nil
+}
@@ -0,0 +1,107 @@
+package roudo_event
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// NewIdleSource は実行中のデスクトップ環境で使える IdleSource を返す。
+// GNOME (Wayland を含む) では Mutter の IdleMonitor、それ以外の Wayland では ext-idle-notify-v1、
+// X11 では XScreenSaver 拡張、どれも使えなければ logind の IdleHint を使う
+func NewIdleSource() (IdleSource, error) {
+	if conn, err := dbus.SessionBus(); err == nil {
+		s := &MutterIdleSource{conn: conn}
+		if _, err := s.IdleTime(); err == nil {
+			return s, nil
+		}
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if s, err := NewWaylandIdleSource(DefaultIdleThreshold); err == nil {
+			return s, nil
+		}
+	}
+	if os.Getenv("DISPLAY") != "" {
+		if path, err := exec.LookPath("xprintidle"); err == nil {
+			return &XScreenSaverIdleSource{path: path}, nil
+		}
+	}
+	if conn, err := dbus.SystemBus(); err == nil {
+		s := &LogindIdleSource{conn: conn}
+		if _, err := s.IdleTime(); err == nil {
+			return s, nil
+		}
+	}
+	return nil, errors.New("アイドル時間を取得できるデスクトップ環境が見つかりません")
+}
+
+// MutterIdleSource は GNOME の org.gnome.Mutter.IdleMonitor からアイドル時間を取得する
+type MutterIdleSource struct {
+	conn *dbus.Conn
+}
+
+func (s *MutterIdleSource) Name() string {
+	return "Mutter"
+}
+
+func (s *MutterIdleSource) IdleTime() (time.Duration, error) {
+	var ms uint64
+	obj := s.conn.Object("org.gnome.Mutter.IdleMonitor", "/org/gnome/Mutter/IdleMonitor/Core")
+	if err := obj.Call("org.gnome.Mutter.IdleMonitor.GetIdletime", 0).Store(&ms); err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// XScreenSaverIdleSource は XScreenSaver 拡張を使う xprintidle コマンドでアイドル時間を取得する
+type XScreenSaverIdleSource struct {
+	path string
+}
+
+func (s *XScreenSaverIdleSource) Name() string {
+	return "XScreenSaver"
+}
+
+func (s *XScreenSaverIdleSource) IdleTime() (time.Duration, error) {
+	out, err := exec.Command(s.path).Output()
+	if err != nil {
+		return 0, err
+	}
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// LogindIdleSource は logind のセッションの IdleHint からアイドル時間を取得する。
+// IdleHint はデスクトップ環境が一定時間操作がないと判断した時に立つため、他の IdleSource よりも粒度が粗い
+type LogindIdleSource struct {
+	conn *dbus.Conn
+}
+
+func (s *LogindIdleSource) Name() string {
+	return "logind"
+}
+
+func (s *LogindIdleSource) IdleTime() (time.Duration, error) {
+	obj := s.conn.Object("org.freedesktop.login1", "/org/freedesktop/login1/session/auto")
+	hint, err := obj.GetProperty("org.freedesktop.login1.Session.IdleHint")
+	if err != nil {
+		return 0, err
+	}
+	if idle, _ := hint.Value().(bool); !idle {
+		return 0, nil
+	}
+	since, err := obj.GetProperty("org.freedesktop.login1.Session.IdleSinceHint")
+	if err != nil {
+		return 0, err
+	}
+	usec, _ := since.Value().(uint64)
+	return time.Since(time.UnixMicro(int64(usec))), nil
+}
@@ -0,0 +1,132 @@
+package roudo_event
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestIdleWatcherObserve(t *testing.T) {
+	type poll struct {
+		// 起動してからの経過時間
+		elapsed  time.Duration
+		idleTime time.Duration
+		want     bool
+	}
+	tests := []struct {
+		name  string
+		polls []poll
+	}{
+		{
+			name: "起動した時に操作中なら送り、threshold までは送らない",
+			polls: []poll{
+				{0, 0, true},
+				{10 * time.Second, time.Second, false},
+				{20 * time.Second, 10 * time.Second, false},
+				{50 * time.Second, 59 * time.Second, false},
+				{59 * time.Second, 0, false},
+			},
+		},
+		{
+			name: "マウスだけを操作し続けていても threshold ごとに送る",
+			polls: []poll{
+				{0, 0, true},
+				{30 * time.Second, 2 * time.Second, false},
+				{time.Minute, 5 * time.Second, true},
+				{90 * time.Second, time.Second, false},
+				{2 * time.Minute, 0, true},
+				{3 * time.Minute, 30 * time.Second, true},
+			},
+		},
+		{
+			name: "起動した時にアイドルなら操作が戻るまで送らない",
+			polls: []poll{
+				{0, 2 * time.Minute, false},
+				{time.Minute, 3 * time.Minute, false},
+				{2 * time.Minute, time.Second, true},
+			},
+		},
+		{
+			name: "アイドルから戻るたびに送る",
+			polls: []poll{
+				{0, 0, true},
+				{5 * time.Second, time.Minute, false},
+				{10 * time.Second, 0, true},
+				{15 * time.Second, 0, false},
+				{20 * time.Second, 5 * time.Minute, false},
+				{25 * time.Second, time.Second, true},
+				{30 * time.Second, 0, false},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewIdleWatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), NewFakeIdleSource(), time.Second, time.Minute)
+			start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+			for i, p := range tt.polls {
+				if got := w.observe(start.Add(p.elapsed), p.idleTime); got != p.want {
+					t.Errorf("%d: observe(+%s, %s) = %v, want %v", i, p.elapsed, p.idleTime, got, p.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIdleWatcherWatch(t *testing.T) {
+	source := NewFakeIdleSource()
+	w := NewIdleWatcher(slog.New(slog.NewTextHandler(io.Discard, nil)), source, time.Millisecond, time.Minute)
+	events := make(chan Event, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Watch(func(e Event) { events <- e })
+	}()
+
+	expect := func(n int) []Event {
+		t.Helper()
+		// 何回かポーリングされるのを待ってから、届いたイベントを数える
+		time.Sleep(50 * time.Millisecond)
+		var got []Event
+		for len(events) != 0 {
+			got = append(got, <-events)
+		}
+		if len(got) != n {
+			t.Fatalf("events = %d, want %d", len(got), n)
+		}
+		return got
+	}
+
+	// 操作が続いていても threshold が経つまでは最初の1回しか送らない
+	expect(1)
+	source.Set(10 * time.Second)
+	expect(0)
+
+	source.Set(5 * time.Minute)
+	expect(0)
+	source.SetError(errors.New("取得できません"))
+	expect(0)
+
+	before := time.Now()
+	source.Set(3 * time.Second)
+	e := expect(1)[0]
+	if e.Kind != KindActive || e.Source != w.Name() {
+		t.Errorf("event = %+v", e)
+	}
+	// 最後に操作した時刻を送る
+	if at := before.Add(-3 * time.Second); e.At.Before(at.Add(-time.Second)) || e.At.After(at.Add(time.Second)) {
+		t.Errorf("At = %s, want about %s", e.At, at)
+	}
+
+	if err := w.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stop しても Watch が終わりません")
+	}
+}
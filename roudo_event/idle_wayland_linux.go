@@ -0,0 +1,271 @@
+package roudo_event
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Wayland のプロトコルのうち、ext-idle-notify-v1 を使うのに必要なものだけを扱う
+const (
+	waylandDisplayID = 1
+
+	// wl_display
+	waylandDisplaySync        = 0
+	waylandDisplayGetRegistry = 1
+	waylandDisplayError       = 0
+	// wl_registry
+	waylandRegistryBind   = 0
+	waylandRegistryGlobal = 0
+	// wl_callback
+	waylandCallbackDone = 0
+	// ext_idle_notifier_v1
+	idleNotifierGetIdleNotification = 1
+	// ext_idle_notification_v1
+	idleNotificationIdled   = 0
+	idleNotificationResumed = 1
+)
+
+// WaylandIdleSource は Wayland の ext-idle-notify-v1 で、アイドルになったことと操作が戻ったことを受け取る。
+// コンポジターからは通知しか届かないので、アイドルになった時刻からアイドル時間を計算する
+type WaylandIdleSource struct {
+	timeout time.Duration
+
+	mu        sync.Mutex
+	idleSince *time.Time
+	err       error
+}
+
+// NewWaylandIdleSource は WAYLAND_DISPLAY のコンポジターに接続し、timeout 操作がなければアイドルとして通知を受け取る
+func NewWaylandIdleSource(timeout time.Duration) (*WaylandIdleSource, error) {
+	display := os.Getenv("WAYLAND_DISPLAY")
+	if display == "" {
+		return nil, errors.New("WAYLAND_DISPLAY が設定されていません")
+	}
+	if !filepath.IsAbs(display) {
+		display = filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), display)
+	}
+	conn, err := net.Dial("unix", display)
+	if err != nil {
+		return nil, err
+	}
+	s, err := newWaylandIdleSource(conn, timeout)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func newWaylandIdleSource(conn io.ReadWriter, timeout time.Duration) (*WaylandIdleSource, error) {
+	c := &waylandConn{rw: conn, nextID: waylandDisplayID + 1}
+
+	registry := c.newID()
+	if err := c.send(waylandDisplayID, waylandDisplayGetRegistry, registry); err != nil {
+		return nil, err
+	}
+	globals, err := c.globals(registry)
+	if err != nil {
+		return nil, err
+	}
+	seat, ok := globals["wl_seat"]
+	if !ok {
+		return nil, errors.New("wl_seat がありません")
+	}
+	notifier, ok := globals["ext_idle_notifier_v1"]
+	if !ok {
+		return nil, errors.New("コンポジターが ext-idle-notify-v1 に対応していません")
+	}
+
+	seatID := c.newID()
+	if err := c.send(registry, waylandRegistryBind, seat, "wl_seat", uint32(1), seatID); err != nil {
+		return nil, err
+	}
+	notifierID := c.newID()
+	if err := c.send(registry, waylandRegistryBind, notifier, "ext_idle_notifier_v1", uint32(1), notifierID); err != nil {
+		return nil, err
+	}
+	notificationID := c.newID()
+	if err := c.send(notifierID, idleNotifierGetIdleNotification, notificationID, uint32(timeout.Milliseconds()), seatID); err != nil {
+		return nil, err
+	}
+
+	s := &WaylandIdleSource{timeout: timeout}
+	go s.receive(c, notificationID)
+	return s, nil
+}
+
+func (s *WaylandIdleSource) Name() string {
+	return "ext-idle-notify"
+}
+
+func (s *WaylandIdleSource) IdleTime() (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	if s.idleSince == nil {
+		return 0, nil
+	}
+	return time.Since(*s.idleSince), nil
+}
+
+// receive は接続が切れるまで通知を受け取る。アイドルの通知は timeout 操作がなかった後に届くので、その分を遡る
+func (s *WaylandIdleSource) receive(c *waylandConn, notificationID uint32) {
+	for {
+		m, err := c.read()
+		if err == nil && m.sender == waylandDisplayID && m.opcode == waylandDisplayError {
+			err = m.displayError()
+		}
+		if err != nil {
+			s.mu.Lock()
+			s.err = fmt.Errorf("Wayland の接続が切れました: %w", err)
+			s.mu.Unlock()
+			return
+		}
+		if m.sender != notificationID {
+			continue
+		}
+
+		s.mu.Lock()
+		switch m.opcode {
+		case idleNotificationIdled:
+			since := time.Now().Add(-s.timeout)
+			s.idleSince = &since
+		case idleNotificationResumed:
+			s.idleSince = nil
+		}
+		s.mu.Unlock()
+	}
+}
+
+// waylandConn は Wayland のメッセージを読み書きする。fd を受け渡すインターフェースは使わない
+type waylandConn struct {
+	rw     io.ReadWriter
+	nextID uint32
+}
+
+type waylandMessage struct {
+	sender uint32
+	opcode uint16
+	args   []byte
+}
+
+func (c *waylandConn) newID() uint32 {
+	id := c.nextID
+	c.nextID++
+	return id
+}
+
+// send は uint32 と string の引数を持つリクエストを送る
+func (c *waylandConn) send(object uint32, opcode uint16, args ...any) error {
+	var body []byte
+	for _, a := range args {
+		switch v := a.(type) {
+		case uint32:
+			body = binary.NativeEndian.AppendUint32(body, v)
+		case string:
+			// 長さは終端の NUL を含み、4バイト単位に詰める
+			body = binary.NativeEndian.AppendUint32(body, uint32(len(v)+1))
+			body = append(body, v...)
+			body = append(body, make([]byte, 4-len(v)%4)...)
+		default:
+			return fmt.Errorf("未対応の引数です: %T", a)
+		}
+	}
+	header := binary.NativeEndian.AppendUint32(nil, object)
+	header = binary.NativeEndian.AppendUint32(header, uint32(8+len(body))<<16|uint32(opcode))
+	_, err := c.rw.Write(append(header, body...))
+	return err
+}
+
+func (c *waylandConn) read() (waylandMessage, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.rw, header); err != nil {
+		return waylandMessage{}, err
+	}
+	sizeOpcode := binary.NativeEndian.Uint32(header[4:])
+	size := sizeOpcode >> 16
+	if size < 8 {
+		return waylandMessage{}, fmt.Errorf("不正なメッセージの長さです: %d", size)
+	}
+	args := make([]byte, size-8)
+	if _, err := io.ReadFull(c.rw, args); err != nil {
+		return waylandMessage{}, err
+	}
+	return waylandMessage{sender: binary.NativeEndian.Uint32(header), opcode: uint16(sizeOpcode), args: args}, nil
+}
+
+// globals は wl_display.sync の応答が届くまでに通知されたグローバルの名前を、インターフェースごとに返す
+func (c *waylandConn) globals(registry uint32) (map[string]uint32, error) {
+	callback := c.newID()
+	if err := c.send(waylandDisplayID, waylandDisplaySync, callback); err != nil {
+		return nil, err
+	}
+	globals := make(map[string]uint32)
+	for {
+		m, err := c.read()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case m.sender == waylandDisplayID && m.opcode == waylandDisplayError:
+			return nil, m.displayError()
+		case m.sender == callback && m.opcode == waylandCallbackDone:
+			return globals, nil
+		case m.sender == registry && m.opcode == waylandRegistryGlobal:
+			name, rest, err := m.uint32(m.args)
+			if err != nil {
+				return nil, err
+			}
+			iface, _, err := m.string(rest)
+			if err != nil {
+				return nil, err
+			}
+			globals[iface] = name
+		}
+	}
+}
+
+func (m waylandMessage) uint32(args []byte) (uint32, []byte, error) {
+	if len(args) < 4 {
+		return 0, nil, errors.New("メッセージが短すぎます")
+	}
+	return binary.NativeEndian.Uint32(args), args[4:], nil
+}
+
+func (m waylandMessage) string(args []byte) (string, []byte, error) {
+	n, rest, err := m.uint32(args)
+	if err != nil {
+		return "", nil, err
+	}
+	padded := int(n+3) / 4 * 4
+	if n == 0 || len(rest) < padded {
+		return "", nil, errors.New("メッセージが短すぎます")
+	}
+	return string(rest[:n-1]), rest[padded:], nil
+}
+
+// displayError は wl_display.error の内容をエラーにする
+func (m waylandMessage) displayError() error {
+	object, rest, err := m.uint32(m.args)
+	if err != nil {
+		return err
+	}
+	code, rest, err := m.uint32(rest)
+	if err != nil {
+		return err
+	}
+	msg, _, err := m.string(rest)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("Wayland のエラーです (object %d, code %d): %s", object, code, msg)
+}
@@ -0,0 +1,147 @@
+package roudo_event
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeCompositor は ext-idle-notify-v1 に必要なリクエストだけに応答するコンポジター
+type fakeCompositor struct {
+	t    *testing.T
+	conn *waylandConn
+
+	notification uint32
+	timeout      uint32
+	seat         uint32
+}
+
+func (f *fakeCompositor) expect(object uint32, opcode uint16) waylandMessage {
+	f.t.Helper()
+	m, err := f.conn.read()
+	if err != nil {
+		f.t.Error(err)
+		return waylandMessage{}
+	}
+	if m.sender != object || m.opcode != opcode {
+		f.t.Errorf("request = %d.%d, want %d.%d", m.sender, m.opcode, object, opcode)
+	}
+	return m
+}
+
+func (f *fakeCompositor) uint32s(m waylandMessage, n int) []uint32 {
+	var vs []uint32
+	args := m.args
+	for i := 0; i < n; i++ {
+		v, rest, err := m.uint32(args)
+		if err != nil {
+			f.t.Error(err)
+			return nil
+		}
+		vs = append(vs, v)
+		args = rest
+	}
+	return vs
+}
+
+// serve はクライアントが通知を購読するまでのやり取りをする
+func (f *fakeCompositor) serve() {
+	registry := f.uint32s(f.expect(waylandDisplayID, waylandDisplayGetRegistry), 1)[0]
+	callback := f.uint32s(f.expect(waylandDisplayID, waylandDisplaySync), 1)[0]
+	f.conn.send(registry, waylandRegistryGlobal, uint32(1), "wl_compositor", uint32(5))
+	f.conn.send(registry, waylandRegistryGlobal, uint32(2), "wl_seat", uint32(7))
+	f.conn.send(registry, waylandRegistryGlobal, uint32(3), "ext_idle_notifier_v1", uint32(1))
+	f.conn.send(callback, waylandCallbackDone, uint32(1))
+
+	bindSeat := f.expect(registry, waylandRegistryBind)
+	name, rest, _ := bindSeat.uint32(bindSeat.args)
+	iface, rest, _ := bindSeat.string(rest)
+	if name != 2 || iface != "wl_seat" {
+		f.t.Errorf("bind = %d %s, want 2 wl_seat", name, iface)
+	}
+	_, rest, _ = bindSeat.uint32(rest)
+	f.seat, _, _ = bindSeat.uint32(rest)
+
+	bindNotifier := f.expect(registry, waylandRegistryBind)
+	name, rest, _ = bindNotifier.uint32(bindNotifier.args)
+	iface, rest, _ = bindNotifier.string(rest)
+	if name != 3 || iface != "ext_idle_notifier_v1" {
+		f.t.Errorf("bind = %d %s, want 3 ext_idle_notifier_v1", name, iface)
+	}
+	_, rest, _ = bindNotifier.uint32(rest)
+	notifier, _, _ := bindNotifier.uint32(rest)
+
+	args := f.uint32s(f.expect(notifier, idleNotifierGetIdleNotification), 3)
+	f.notification, f.timeout = args[0], args[1]
+	if args[2] != f.seat {
+		f.t.Errorf("seat = %d, want %d", args[2], f.seat)
+	}
+}
+
+func TestWaylandIdleSource(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	compositor := &fakeCompositor{t: t, conn: &waylandConn{rw: server}}
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		compositor.serve()
+	}()
+
+	s, err := newWaylandIdleSource(client, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-served
+	if compositor.timeout != uint32(time.Minute.Milliseconds()) {
+		t.Errorf("timeout = %d, want %d", compositor.timeout, time.Minute.Milliseconds())
+	}
+
+	idleTime := func() time.Duration {
+		t.Helper()
+		// 通知は別の goroutine で受け取るので、少し待ってから問い合わせる
+		time.Sleep(20 * time.Millisecond)
+		d, err := s.IdleTime()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	if d := idleTime(); d != 0 {
+		t.Errorf("IdleTime = %s, want 0", d)
+	}
+	compositor.conn.send(compositor.notification, idleNotificationIdled)
+	if d := idleTime(); d < time.Minute || d > time.Minute+time.Second {
+		t.Errorf("IdleTime = %s, want about 1m", d)
+	}
+	// 他のオブジェクトへのイベントは無視する
+	compositor.conn.send(compositor.seat, 1, "seat0")
+	compositor.conn.send(compositor.notification, idleNotificationResumed)
+	if d := idleTime(); d != 0 {
+		t.Errorf("IdleTime = %s, want 0", d)
+	}
+
+	server.Close()
+	time.Sleep(20 * time.Millisecond)
+	if _, err := s.IdleTime(); err == nil {
+		t.Error("接続が切れてもエラーになりません")
+	}
+}
+
+func TestWaylandIdleSourceUnsupported(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		c := &waylandConn{rw: server}
+		c.read()
+		m, _ := c.read()
+		callback, _, _ := m.uint32(m.args)
+		c.send(2, waylandRegistryGlobal, uint32(1), "wl_seat", uint32(7))
+		c.send(callback, waylandCallbackDone, uint32(1))
+	}()
+
+	if _, err := newWaylandIdleSource(client, time.Minute); err == nil {
+		t.Error("ext-idle-notify-v1 がないのにエラーになりません")
+	}
+}
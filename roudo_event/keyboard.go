@@ -1,3 +1,5 @@
+//go:build darwin
+
 package roudo_event
 
 import (
@@ -20,3 +22,8 @@ func (w *KeyboardEventWatcher) Watch(onEvent func()) error {
 	<-hook.Process(s)
 	return nil
 }
+
+func (w *KeyboardEventWatcher) Stop() error {
+	hook.End()
+	return nil
+}
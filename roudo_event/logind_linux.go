@@ -0,0 +1,152 @@
+package roudo_event
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	logindName             = "org.freedesktop.login1"
+	logindPath             = dbus.ObjectPath("/org/freedesktop/login1")
+	logindManagerInterface = "org.freedesktop.login1.Manager"
+	logindSessionInterface = "org.freedesktop.login1.Session"
+)
+
+// LogindWatcher は logind の PrepareForSleep と、セッションの Lock/Unlock シグナルを監視する。
+// スリープの前に休憩の開始を記録できるよう、sleep の delay inhibitor を取っておき、記録してから手放す。
+// テストでは conn にプライベートなバスへの接続を渡し、同じシグナルを送ればよい
+type LogindWatcher struct {
+	logger  *slog.Logger
+	conn    *dbus.Conn
+	stopper stopper
+
+	inhibitor *os.File
+}
+
+func NewLogindWatcher(logger *slog.Logger, conn *dbus.Conn) *LogindWatcher {
+	return &LogindWatcher{
+		logger: logger,
+		conn:   conn,
+	}
+}
+
+func (w *LogindWatcher) Name() string {
+	return "LogindWatcher"
+}
+
+func (w *LogindWatcher) Watch(onEvent func(e Event)) error {
+	if err := w.conn.AddMatchSignal(
+		dbus.WithMatchInterface(logindManagerInterface),
+		dbus.WithMatchMember("PrepareForSleep"),
+	); err != nil {
+		return err
+	}
+	sessionPath := w.sessionPath()
+	sessionMatch := []dbus.MatchOption{dbus.WithMatchInterface(logindSessionInterface)}
+	if sessionPath != "" {
+		sessionMatch = append(sessionMatch, dbus.WithMatchObjectPath(sessionPath))
+	}
+	if err := w.conn.AddMatchSignal(sessionMatch...); err != nil {
+		return err
+	}
+
+	ch := make(chan *dbus.Signal, 16)
+	w.conn.Signal(ch)
+	defer w.conn.RemoveSignal(ch)
+
+	w.inhibit()
+	defer w.release()
+
+	for {
+		var sig *dbus.Signal
+		select {
+		case <-w.stopper.done():
+			return nil
+		case s, ok := <-ch:
+			if !ok {
+				return errors.New("D-Bus の接続が切れました")
+			}
+			sig = s
+		}
+
+		var kind Kind
+		switch sig.Name {
+		case logindManagerInterface + ".PrepareForSleep":
+			if len(sig.Body) == 0 {
+				continue
+			}
+			start, ok := sig.Body[0].(bool)
+			if !ok {
+				continue
+			}
+			kind = KindWake
+			if start {
+				kind = KindSleep
+			}
+		case logindSessionInterface + ".Lock":
+			kind = KindLock
+		case logindSessionInterface + ".Unlock":
+			kind = KindUnlock
+		default:
+			continue
+		}
+		if sessionPath != "" && sig.Path != logindPath && sig.Path != sessionPath {
+			continue
+		}
+		w.logger.Debug("session event", slog.String("kind", string(kind)), slog.String("path", string(sig.Path)))
+		onEvent(Event{Source: w.Name(), Kind: kind, At: time.Now(), Meta: map[string]string{"path": string(sig.Path)}})
+
+		// 休憩の開始を記録したのでスリープさせ、復帰したら次のスリープに備える
+		switch kind {
+		case KindSleep:
+			w.release()
+		case KindWake:
+			w.inhibit()
+		}
+	}
+}
+
+// inhibit は sleep の delay inhibitor を取る。取れなければ、スリープした後に届いたシグナルで記録する
+func (w *LogindWatcher) inhibit() {
+	if w.inhibitor != nil {
+		return
+	}
+	var fd dbus.UnixFD
+	err := w.conn.Object(logindName, logindPath).Call(logindManagerInterface+".Inhibit", 0, "sleep", "roudo", "スリープの前に休憩の開始を記録するため", "delay").Store(&fd)
+	if err != nil {
+		w.logger.Warn("failed to take logind inhibitor", slog.String("error", err.Error()))
+		return
+	}
+	w.inhibitor = os.NewFile(uintptr(fd), "logind-inhibitor")
+}
+
+// release は delay inhibitor を手放し、logind がスリープを続けられるようにする
+func (w *LogindWatcher) release() {
+	if w.inhibitor == nil {
+		return
+	}
+	if err := w.inhibitor.Close(); err != nil {
+		w.logger.Warn("failed to release logind inhibitor", slog.String("error", err.Error()))
+	}
+	w.inhibitor = nil
+}
+
+func (w *LogindWatcher) Stop() error {
+	w.stopper.stop()
+	return nil
+}
+
+// sessionPath は自分のプロセスが属するセッションのオブジェクトパスを返す。見つからなければ全てのセッションを監視する
+func (w *LogindWatcher) sessionPath() dbus.ObjectPath {
+	var path dbus.ObjectPath
+	err := w.conn.Object(logindName, logindPath).Call(logindManagerInterface+".GetSessionByPID", 0, uint32(os.Getpid())).Store(&path)
+	if err != nil {
+		w.logger.Warn("failed to get logind session. watch all sessions", slog.String("error", err.Error()))
+		return ""
+	}
+	return path
+}
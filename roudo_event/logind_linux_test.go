@@ -0,0 +1,212 @@
+package roudo_event
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const testBusConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:path=%s</listen>
+  <policy context="default">
+    <allow send_destination="*"/>
+    <allow receive_sender="*"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// startTestBus はテスト用のプライベートな D-Bus を起動し、そのアドレスを返す
+func startTestBus(t *testing.T) string {
+	t.Helper()
+	bin, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon がありません")
+	}
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "bus")
+	config := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(config, []byte(strings.Replace(testBusConfig, "%s", socket, 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(bin, "--config-file="+config, "--nofork")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(socket); err == nil {
+			return "unix:path=" + socket
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("dbus-daemon が起動しませんでした")
+	return ""
+}
+
+// fakeLogind は login1.Manager の GetSessionByPID と Inhibit だけを持つ logind
+type fakeLogind struct {
+	mu sync.Mutex
+	// inhibitors は Inhibit で渡した fd の読み込み側。全ての書き込み側が閉じられると EOF になる
+	inhibitors []*os.File
+	sent       []*os.File
+}
+
+func (l *fakeLogind) GetSessionByPID(pid uint32) (dbus.ObjectPath, *dbus.Error) {
+	return "/org/freedesktop/login1/session/_31", nil
+}
+
+func (l *fakeLogind) Inhibit(what, who, why, mode string) (dbus.UnixFD, *dbus.Error) {
+	if what != "sleep" || mode != "delay" {
+		return 0, dbus.MakeFailedError(io.ErrUnexpectedEOF)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return 0, dbus.MakeFailedError(err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inhibitors = append(l.inhibitors, r)
+	l.sent = append(l.sent, w)
+	return dbus.UnixFD(w.Fd()), nil
+}
+
+// released は i 番目の inhibitor が手放されるのを待つ
+func (l *fakeLogind) released(t *testing.T, i int) bool {
+	t.Helper()
+	l.mu.Lock()
+	if len(l.inhibitors) <= i {
+		l.mu.Unlock()
+		return false
+	}
+	r, w := l.inhibitors[i], l.sent[i]
+	l.mu.Unlock()
+
+	// 送った後の自分の書き込み側を閉じ、残りは watcher が持っているものだけにする
+	w.Close()
+	r.SetReadDeadline(time.Now().Add(time.Second))
+	_, err := r.Read(make([]byte, 1))
+	return err == io.EOF
+}
+
+func (l *fakeLogind) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.inhibitors)
+}
+
+func TestLogindWatcher(t *testing.T) {
+	addr := startTestBus(t)
+
+	server, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	logind := &fakeLogind{}
+	if err := server.Export(logind, logindPath, logindManagerInterface); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := server.RequestName(logindName, dbus.NameFlagDoNotQueue); err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("RequestName: %v %v", reply, err)
+	}
+
+	conn, err := dbus.Connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	w := NewLogindWatcher(logger, conn)
+	events := make(chan Event, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Watch(func(e Event) { events <- e })
+	}()
+
+	wait := func(want Kind) Event {
+		t.Helper()
+		select {
+		case e := <-events:
+			if e.Kind != want {
+				t.Fatalf("kind = %s, want %s", e.Kind, want)
+			}
+			return e
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s のイベントが届きませんでした", want)
+		}
+		return Event{}
+	}
+	// Watch が購読を始めて inhibitor を取るまで待つ
+	for i := 0; logind.count() == 0; i++ {
+		if i == 100 {
+			t.Fatal("inhibitor を取りませんでした")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 引数のない壊れたシグナルでは止まらない
+	if err := server.Emit(logindPath, logindManagerInterface+".PrepareForSleep"); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Emit(logindPath, logindManagerInterface+".PrepareForSleep", true); err != nil {
+		t.Fatal(err)
+	}
+	wait(KindSleep)
+	if !logind.released(t, 0) {
+		t.Error("スリープを記録した後に inhibitor を手放していません")
+	}
+
+	if err := server.Emit(logindPath, logindManagerInterface+".PrepareForSleep", false); err != nil {
+		t.Fatal(err)
+	}
+	wait(KindWake)
+	for i := 0; logind.count() < 2; i++ {
+		if i == 100 {
+			t.Fatal("復帰した後に inhibitor を取り直していません")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := server.Emit("/org/freedesktop/login1/session/_31", logindSessionInterface+".Lock"); err != nil {
+		t.Fatal(err)
+	}
+	wait(KindLock)
+	// 他のセッションのロックは無視する
+	if err := server.Emit("/org/freedesktop/login1/session/_32", logindSessionInterface+".Unlock"); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Emit("/org/freedesktop/login1/session/_31", logindSessionInterface+".Unlock"); err != nil {
+		t.Fatal(err)
+	}
+	e := wait(KindUnlock)
+	if e.Meta["path"] != "/org/freedesktop/login1/session/_31" {
+		t.Errorf("path = %s", e.Meta["path"])
+	}
+
+	if err := w.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if !logind.released(t, 1) {
+		t.Error("止めた後に inhibitor を手放していません")
+	}
+}
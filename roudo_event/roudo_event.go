@@ -1,17 +1,123 @@
 package roudo_event
 
-import "log/slog"
+import (
+	"sync"
+	"time"
+)
 
+type Kind string
+
+const (
+	// KindKey はキーボードの入力
+	KindKey = Kind("key")
+	// KindPointer はマウスやタッチパッドの操作
+	KindPointer = Kind("pointer")
+	// KindActive はアイドル時間などから推定した、種類の分からない操作
+	KindActive = Kind("active")
+	// KindContext はアクティブなウィンドウや作業ディレクトリの切り替え。Meta に ActivityContext を持つ
+	KindContext = Kind("context")
+
+	// 画面ロックとスリープ。入力イベントとしては扱わない
+	KindLock   = Kind("lock")
+	KindUnlock = Kind("unlock")
+	KindSleep  = Kind("sleep")
+	KindWake   = Kind("wake")
+)
+
+// IsSession は画面ロック・スリープのイベントかを返す
+func (k Kind) IsSession() bool {
+	switch k {
+	case KindLock, KindUnlock, KindSleep, KindWake:
+		return true
+	}
+	return false
+}
+
+type Event struct {
+	// イベントを発火した Watcher の名前
+	Source string
+	Kind   Kind
+	// イベントの発生時刻。ゼロ値の場合は受け取った時刻とみなす
+	At time.Time
+	// デバイス名などの付加情報
+	Meta map[string]string
+}
+
+// Watcher は Stop が呼ばれるまで Watch の中でイベントを送り続ける。Stop された後の Watch は nil を返す
 type Watcher interface {
+	Name() string
+	Watch(onEvent func(e Event)) error
+	Stop() error
+}
+
+// LegacyWatcher は発火したことだけを通知する以前の Watcher
+type LegacyWatcher interface {
 	Name() string
 	Watch(onEvent func()) error
 }
 
-func NewAllWatchers(logger *slog.Logger) []Watcher {
-	return []Watcher{
-		&KeyboardEventWatcher{},
-		&MouseEventWatcher{
-			logger: logger,
-		},
+// AdaptLegacy は LegacyWatcher を、発火した時刻の kind のイベントを送る Watcher にする。
+// w が Stop() error を持っていれば Stop で呼ぶ
+func AdaptLegacy(w LegacyWatcher, kind Kind) Watcher {
+	return &legacyWatcher{w: w, kind: kind}
+}
+
+type legacyWatcher struct {
+	w    LegacyWatcher
+	kind Kind
+}
+
+func (l *legacyWatcher) Name() string {
+	return l.w.Name()
+}
+
+func (l *legacyWatcher) Watch(onEvent func(e Event)) error {
+	return l.w.Watch(func() {
+		onEvent(Event{Source: l.w.Name(), Kind: l.kind, At: time.Now()})
+	})
+}
+
+func (l *legacyWatcher) Stop() error {
+	if s, ok := l.w.(interface{ Stop() error }); ok {
+		return s.Stop()
+	}
+	return nil
+}
+
+// stopper は Stop でポーリングのループを終わらせるためのもの。ゼロ値で使える
+type stopper struct {
+	mu      sync.Mutex
+	ch      chan struct{}
+	stopped bool
+}
+
+func (s *stopper) done() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	return s.ch
+}
+
+func (s *stopper) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ch == nil {
+		s.ch = make(chan struct{})
+	}
+	if !s.stopped {
+		close(s.ch)
+		s.stopped = true
+	}
+}
+
+// sleep は d だけ待つ。途中で止められたら false を返す
+func (s *stopper) sleep(d time.Duration) bool {
+	select {
+	case <-s.done():
+		return false
+	case <-time.After(d):
+		return true
 	}
 }
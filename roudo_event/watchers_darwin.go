@@ -0,0 +1,15 @@
+package roudo_event
+
+import "log/slog"
+
+func NewAllWatchers(logger *slog.Logger) []Watcher {
+	ws := []Watcher{
+		AdaptLegacy(&KeyboardEventWatcher{}, KindKey),
+	}
+	if source, err := NewIdleSource(); err == nil {
+		ws = append(ws, NewIdleWatcher(logger, source, DefaultIdleInterval, DefaultIdleThreshold))
+	} else {
+		logger.Warn("idle source is unavailable", slog.String("error", err.Error()))
+	}
+	return ws
+}
@@ -0,0 +1,29 @@
+package roudo_event
+
+import (
+	"log/slog"
+
+	"github.com/godbus/dbus/v5"
+)
+
+func NewAllWatchers(logger *slog.Logger) []Watcher {
+	ws := []Watcher{
+		NewEvdevWatcher(logger, DefaultEvdevDir),
+	}
+	if source, err := NewIdleSource(); err == nil {
+		ws = append(ws, NewIdleWatcher(logger, source, DefaultIdleInterval, DefaultIdleThreshold))
+	} else {
+		logger.Warn("idle source is unavailable", slog.String("error", err.Error()))
+	}
+	if w, err := NewX11ContextWatcher(logger, DefaultContextInterval); err == nil {
+		ws = append(ws, w)
+	} else {
+		logger.Warn("activity context is unavailable", slog.String("error", err.Error()))
+	}
+	if conn, err := dbus.SystemBus(); err == nil {
+		ws = append(ws, NewLogindWatcher(logger, conn))
+	} else {
+		logger.Warn("logind is unavailable", slog.String("error", err.Error()))
+	}
+	return ws
+}
@@ -0,0 +1,9 @@
+//go:build !darwin && !linux
+
+package roudo_event
+
+import "log/slog"
+
+func NewAllWatchers(logger *slog.Logger) []Watcher {
+	return []Watcher{}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"roudo/roudo"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+var statusCommand = &cli.Command{
+	Name:  "status",
+	Usage: "現在の労働状態を表示",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "出力形式 (text, json)",
+			Value: "text",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		format := c.String("format")
+		if format != "text" && format != "json" {
+			return fmt.Errorf("未対応の出力形式です: %s", format)
+		}
+
+		ctrl, closeCtrl, err := openController(c)
+		if err != nil {
+			return err
+		}
+		defer closeCtrl()
+
+		st, err := ctrl.GetStatus(time.Now())
+		if err != nil {
+			return err
+		}
+
+		if format == "json" {
+			return writeStatusJSON(c.App.Writer, st)
+		}
+		return writeStatusText(c.App.Writer, st)
+	},
+}
+
+var stateLabels = map[roudo.RoudoState]string{
+	roudo.RoudoStateOff:      "労働外",
+	roudo.RoudoStateWorking:  "労働中",
+	roudo.RoudoStateBreaking: "休憩中",
+}
+
+func writeStatusText(w io.Writer, st *roudo.RoudoStatus) error {
+	lines := [][2]string{
+		{"状態", stateLabels[st.State]},
+		{"日付", string(st.Date)},
+	}
+	if st.SessionStartAt != nil {
+		lines = append(lines, [2]string{"労働開始", st.SessionStartAt.Local().Format("15:04")})
+	}
+	if st.BreakStartAt != nil {
+		lines = append(lines, [2]string{"休憩開始", st.BreakStartAt.Local().Format("15:04")})
+	}
+	lines = append(lines,
+		[2]string{"労働時間", formatDuration(st.WorkingTime)},
+		[2]string{"休憩時間", formatDuration(st.BreakTime)},
+	)
+	if st.UntilAutoBreak != nil {
+		lines = append(lines, [2]string{"自動休憩まで", formatDuration(*st.UntilAutoBreak)})
+	}
+	if st.UntilAutoFinish != nil {
+		lines = append(lines, [2]string{"自動終了まで", formatDuration(*st.UntilAutoFinish)})
+	}
+
+	for _, l := range lines {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", l[0], l[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type statusJSON struct {
+	State                  roudo.RoudoState `json:"state"`
+	Date                   roudo.Date       `json:"date"`
+	SessionStartAt         *time.Time       `json:"session_start_at"`
+	BreakStartAt           *time.Time       `json:"break_start_at"`
+	LastEventAt            *time.Time       `json:"last_event_at"`
+	WorkingSeconds         int64            `json:"working_seconds"`
+	BreakSeconds           int64            `json:"break_seconds"`
+	UntilAutoBreakSeconds  *int64           `json:"until_auto_break_seconds"`
+	UntilAutoFinishSeconds *int64           `json:"until_auto_finish_seconds"`
+}
+
+func writeStatusJSON(w io.Writer, st *roudo.RoudoStatus) error {
+	return json.NewEncoder(w).Encode(statusJSON{
+		State:                  st.State,
+		Date:                   st.Date,
+		SessionStartAt:         st.SessionStartAt,
+		BreakStartAt:           st.BreakStartAt,
+		LastEventAt:            st.LastEventAt,
+		WorkingSeconds:         int64(st.WorkingTime.Seconds()),
+		BreakSeconds:           int64(st.BreakTime.Seconds()),
+		UntilAutoBreakSeconds:  durationSecondsPtr(st.UntilAutoBreak),
+		UntilAutoFinishSeconds: durationSecondsPtr(st.UntilAutoFinish),
+	})
+}
+
+func durationSecondsPtr(d *time.Duration) *int64 {
+	if d == nil {
+		return nil
+	}
+	s := int64(d.Seconds())
+	return &s
+}
+
+func formatDuration(d time.Duration) string {
+	m := int(d.Minutes())
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"roudo/roudo"
+	"testing"
+	"time"
+)
+
+func TestWriteStatusJSON(t *testing.T) {
+	at := func(hour, min int) *time.Time {
+		t := time.Date(2024, 3, 1, hour, min, 0, 0, time.UTC)
+		return &t
+	}
+	duration := func(d time.Duration) *time.Duration {
+		return &d
+	}
+	tests := []struct {
+		name string
+		st   roudo.RoudoStatus
+		want string
+	}{
+		{
+			name: "労働外",
+			st:   roudo.RoudoStatus{State: roudo.RoudoStateOff, Date: "2024-03-01"},
+			want: `{"state":"off","date":"2024-03-01","session_start_at":null,"break_start_at":null,"last_event_at":null,"working_seconds":0,"break_seconds":0,"until_auto_break_seconds":null,"until_auto_finish_seconds":null}`,
+		},
+		{
+			name: "労働中",
+			st: roudo.RoudoStatus{
+				State:          roudo.RoudoStateWorking,
+				Date:           "2024-03-01",
+				SessionStartAt: at(9, 0),
+				LastEventAt:    at(11, 50),
+				WorkingTime:    2*time.Hour + 30*time.Minute + 500*time.Millisecond,
+				BreakTime:      30 * time.Minute,
+				UntilAutoBreak: duration(25 * time.Minute),
+			},
+			want: `{"state":"working","date":"2024-03-01","session_start_at":"2024-03-01T09:00:00Z","break_start_at":null,"last_event_at":"2024-03-01T11:50:00Z","working_seconds":9000,"break_seconds":1800,"until_auto_break_seconds":1500,"until_auto_finish_seconds":null}`,
+		},
+		{
+			name: "休憩中",
+			st: roudo.RoudoStatus{
+				State:           roudo.RoudoStateBreaking,
+				Date:            "2024-03-01",
+				SessionStartAt:  at(9, 0),
+				BreakStartAt:    at(12, 0),
+				LastEventAt:     at(12, 0),
+				WorkingTime:     3 * time.Hour,
+				BreakTime:       10 * time.Minute,
+				UntilAutoFinish: duration(0),
+			},
+			want: `{"state":"breaking","date":"2024-03-01","session_start_at":"2024-03-01T09:00:00Z","break_start_at":"2024-03-01T12:00:00Z","last_event_at":"2024-03-01T12:00:00Z","working_seconds":10800,"break_seconds":600,"until_auto_break_seconds":null,"until_auto_finish_seconds":0}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeStatusJSON(&buf, &tt.st); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want+"\n" {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"roudo/roudo"
+
+	"github.com/tidwall/buntdb"
+	"github.com/urfave/cli/v2"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	storageBuntDB = "buntdb"
+	storageSQLite = "sqlite"
+	storageJSON   = "json"
+)
+
+// memoryDB を --db に指定するとメモリ上にだけ保存する
+const memoryDB = ":memory:"
+
+var storages = []string{storageBuntDB, storageSQLite, storageJSON}
+
+// openRepository は storage に応じた RoudoReportRepository と、それを閉じる関数を返す。
+// path が空なら dir 以下の既定の保存先を使い、memoryDB ならメモリ上にだけ保存する
+func openRepository(dir, storage, path string) (roudo.RoudoReportRepository, func() error, error) {
+	if path == memoryDB {
+		return roudo.NewMemoryRoudoReportRepository(), func() error { return nil }, nil
+	}
+	if path == "" {
+		path = defaultRepositoryPath(dir, storage)
+	}
+
+	switch storage {
+	case storageBuntDB:
+		db, err := buntdb.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return roudo.NewRoudoReportRepository(db), db.Close, nil
+	case storageSQLite:
+		db, err := sql.Open("sqlite", path)
+		if err != nil {
+			return nil, nil, err
+		}
+		db.SetMaxOpenConns(1)
+		repo, err := roudo.NewSQLiteRoudoReportRepository(db)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+		return repo, db.Close, nil
+	case storageJSON:
+		repo, err := roudo.NewJSONRoudoReportRepository(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo, func() error { return nil }, nil
+	}
+	return nil, nil, fmt.Errorf("未対応の storage です: %s", storage)
+}
+
+func defaultRepositoryPath(dir, storage string) string {
+	switch storage {
+	case storageSQLite:
+		return filepath.Join(dir, "roudo.sqlite")
+	case storageJSON:
+		return filepath.Join(dir, "reports")
+	}
+	return filepath.Join(dir, "roudo.db")
+}
+
+var migrateCommand = &cli.Command{
+	Name:  "migrate",
+	Usage: "保存先の間で全ての勤怠データをコピー",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "コピー元 (buntdb, sqlite, json)",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "to",
+			Usage:    "コピー先 (buntdb, sqlite, json)",
+			Required: true,
+		},
+	},
+	Action: func(c *cli.Context) error {
+		from, to := c.String("from"), c.String("to")
+		if from == to {
+			return fmt.Errorf("コピー元とコピー先が同じです: %s", from)
+		}
+
+		cfg, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+		if err := refuseWhileDaemonRunning(cfg.Dir); err != nil {
+			return err
+		}
+
+		src, closeSrc, err := openRepository(cfg.Dir, from, "")
+		if err != nil {
+			return err
+		}
+		defer closeSrc()
+		dst, closeDst, err := openRepository(cfg.Dir, to, "")
+		if err != nil {
+			return err
+		}
+		defer closeDst()
+
+		fm, err := newFileMutex(cfg.Dir)
+		if err != nil {
+			return err
+		}
+		logger, err := newLogger(cfg.Dir)
+		if err != nil {
+			return err
+		}
+		reporter := roudo.NewRoudoReporter(dst, roudo.NewFileAuditLog(auditLogPath(cfg.Dir)), logger, &roudo.NopNotificator{}, fm, roudo.NewSystemClock(), cfg.Config)
+
+		n, err := reporter.Migrate(src)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.App.Writer, "%s から %s へ %d 日分をコピーしました\n", from, to, n)
+		return nil
+	},
+}
@@ -0,0 +1,393 @@
+package view
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"roudo/roudo"
+	"strings"
+	"time"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV      = ExportFormat("csv")
+	ExportFormatJSON     = ExportFormat("json")
+	ExportFormatMarkdown = ExportFormat("md")
+	ExportFormatHTML     = ExportFormat("html")
+	ExportFormatTable    = ExportFormat("table")
+)
+
+type exporter struct {
+	repo     ViewRepository
+	calendar *roudo.HolidayCalendar
+	format   ExportFormat
+	w        io.Writer
+}
+
+func NewExporter(repo ViewRepository, calendar *roudo.HolidayCalendar, format ExportFormat, w io.Writer) (Viewer, error) {
+	switch format {
+	case ExportFormatCSV, ExportFormatJSON, ExportFormatMarkdown, ExportFormatHTML:
+		return &exporter{repo: repo, calendar: calendar, format: format, w: w}, nil
+	case ExportFormatTable:
+		return &tableViewer{repo: repo, calendar: calendar, w: w}, nil
+	}
+	return nil, fmt.Errorf("未対応の出力形式です: %s", format)
+}
+
+func (e *exporter) Do(p Period) error {
+	reports, err := e.repo.ListReports(p)
+	if err != nil {
+		return err
+	}
+	preceding, err := listPrecedingWeek(e.repo, p)
+	if err != nil {
+		return err
+	}
+	ov, err := newOvertimeSummary(reports, preceding, e.calendar)
+	if err != nil {
+		return err
+	}
+
+	switch e.format {
+	case ExportFormatCSV:
+		return writeCSV(e.w, reports, ov)
+	case ExportFormatJSON:
+		return writeJSON(e.w, p.Label(), reports, ov)
+	case ExportFormatMarkdown:
+		return writeMarkdown(e.w, reports, ov)
+	case ExportFormatHTML:
+		return writeHTML(e.w, p.Label(), reports, ov)
+	}
+	return nil
+}
+
+// overtimeSummary は出力に必要な時間外労働と休日の情報をまとめたもの
+type overtimeSummary struct {
+	byDate       map[roudo.Date]roudo.Overtime
+	total        roudo.Overtime
+	holidays     map[roudo.Date]string
+	workedDays   int
+	businessDays int
+	// タグが1つもなければ nil
+	projects []roudo.ProjectTotal
+}
+
+func newOvertimeSummary(reports, preceding roudoReportForView, calendar *roudo.HolidayCalendar) (*overtimeSummary, error) {
+	dailies, total, err := roudo.CalcOvertime(reports, preceding, calendar.IsLegalHoliday)
+	if err != nil {
+		return nil, err
+	}
+	worked, business, err := countWorkingDays(reports, calendar)
+	if err != nil {
+		return nil, err
+	}
+	ov := &overtimeSummary{
+		byDate:       make(map[roudo.Date]roudo.Overtime),
+		total:        total,
+		holidays:     make(map[roudo.Date]string),
+		workedDays:   worked,
+		businessDays: business,
+	}
+	if roudo.HasTags(reports) {
+		ov.projects = roudo.CalcProjectTotals(reports)
+	}
+	for _, d := range dailies {
+		ov.byDate[d.Date] = d.Overtime
+		if name := holidayName(d.Date, calendar); name != "" {
+			ov.holidays[d.Date] = name
+		}
+	}
+	return ov, nil
+}
+
+// footer は月の合計行を返す
+func (ov *overtimeSummary) footer() []string {
+	return []string{"", "", "", "", "", "総労働時間", durationToString(ov.total.WorkingTime), durationToString(ov.total.Total()), durationToString(ov.total.LateNight), durationToString(ov.total.Holiday), "", ""}
+}
+
+// projectRows はプロジェクトごとの労働時間の合計行を返す
+func (ov *overtimeSummary) projectRows() [][]string {
+	rows := make([][]string, 0, len(ov.projects))
+	for _, p := range ov.projects {
+		rows = append(rows, []string{projectLabel(p.Project), durationToString(p.WorkingTime)})
+	}
+	return rows
+}
+
+func (ov *overtimeSummary) workingDaysText() string {
+	return fmt.Sprintf("出勤日数: %d / 所定労働日数: %d", ov.workedDays, ov.businessDays)
+}
+
+// projectLabel はタグのない労働時間を「未分類」と表示する
+func projectLabel(project string) string {
+	if project == roudo.UntaggedProject {
+		return "未分類"
+	}
+	return project
+}
+
+// dailyProjectsText は1日のプロジェクトごとの労働時間を「A 01:00, B 02:00」の形式で返す
+func dailyProjectsText(report roudo.DailyReport) string {
+	if !roudo.HasTags([]roudo.DailyReport{report}) {
+		return ""
+	}
+	var parts []string
+	for _, p := range roudo.CalcProjectTotals([]roudo.DailyReport{report}) {
+		parts = append(parts, projectLabel(p.Project)+" "+durationToString(p.WorkingTime))
+	}
+	return strings.Join(parts, ", ")
+}
+
+var exportHeader = []string{"日付", "労働開始", "労働終了", "休憩開始", "休憩終了", "休憩時間", "労働時間", "時間外", "深夜", "休日", "祝日", "プロジェクト"}
+
+// exportRows は Flatten した1行ごとに、その日の休憩時間・労働時間・時間外労働の合計を付けた行を返す
+func exportRows(reports roudoReportForView, ov *overtimeSummary) [][]string {
+	var rows [][]string
+	for _, f := range reports.Flatten() {
+		rs := reports.FindByDate(f.Date)
+		o := ov.byDate[f.Date]
+		row := []string{
+			string(f.Date),
+			ptrTimeToString(f.Roudo.StartAt),
+			ptrTimeToString(f.Roudo.EndAt),
+			"",
+			"",
+			durationToString(calculateTotalBreakTime(rs)),
+			durationToString(calculateTotalWorkTime(rs)),
+			durationToString(o.Total()),
+			durationToString(o.LateNight),
+			durationToString(o.Holiday),
+			ov.holidays[f.Date],
+			dailyProjectsText(roudo.DailyReport{Date: f.Date, Roudos: rs}),
+		}
+		if f.Break != nil {
+			row[3] = f.Break.StartAt.Format("15:04")
+			row[4] = ptrTimeToString(f.Break.EndAt)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func writeCSV(w io.Writer, reports roudoReportForView, ov *overtimeSummary) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"date", "start_at", "end_at", "break_start_at", "break_end_at", "daily_break_time", "daily_working_time", "daily_overtime", "daily_late_night", "daily_holiday_work", "holiday", "daily_projects"}); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(exportRows(reports, ov)); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type exportBreak struct {
+	StartAt time.Time  `json:"start_at"`
+	EndAt   *time.Time `json:"end_at"`
+}
+
+type exportTag struct {
+	Project string     `json:"project"`
+	Task    string     `json:"task,omitempty"`
+	StartAt time.Time  `json:"start_at"`
+	EndAt   *time.Time `json:"end_at"`
+}
+
+type exportActivity struct {
+	Label   string     `json:"label"`
+	StartAt time.Time  `json:"start_at"`
+	EndAt   *time.Time `json:"end_at"`
+}
+
+type exportSession struct {
+	StartAt    *time.Time       `json:"start_at"`
+	EndAt      *time.Time       `json:"end_at"`
+	Breaks     []exportBreak    `json:"breaks"`
+	Tags       []exportTag      `json:"tags,omitempty"`
+	Activities []exportActivity `json:"activities,omitempty"`
+}
+
+type exportProject struct {
+	Project     string `json:"project"`
+	WorkingTime string `json:"working_time"`
+}
+
+func newExportProjects(totals []roudo.ProjectTotal) []exportProject {
+	if totals == nil {
+		return nil
+	}
+	ps := make([]exportProject, 0, len(totals))
+	for _, p := range totals {
+		ps = append(ps, exportProject{Project: p.Project, WorkingTime: durationToString(p.WorkingTime)})
+	}
+	return ps
+}
+
+type exportDay struct {
+	Date        roudo.Date      `json:"date"`
+	Sessions    []exportSession `json:"sessions"`
+	BreakTime   string          `json:"break_time"`
+	WorkingTime string          `json:"working_time"`
+	Overtime    string          `json:"overtime"`
+	LateNight   string          `json:"late_night"`
+	HolidayWork string          `json:"holiday_work"`
+	Holiday     string          `json:"holiday,omitempty"`
+	Projects    []exportProject `json:"projects,omitempty"`
+}
+
+type exportMonth struct {
+	Month            string          `json:"month"`
+	Days             []exportDay     `json:"days"`
+	TotalWorkingTime string          `json:"total_working_time"`
+	TotalOvertime    string          `json:"total_overtime"`
+	TotalLateNight   string          `json:"total_late_night"`
+	TotalHolidayWork string          `json:"total_holiday_work"`
+	OvertimeOver60   string          `json:"overtime_over_60"`
+	WorkedDays       int             `json:"worked_days"`
+	BusinessDays     int             `json:"business_days"`
+	ProjectTotals    []exportProject `json:"project_totals,omitempty"`
+}
+
+func writeJSON(w io.Writer, yearMonth string, reports roudoReportForView, ov *overtimeSummary) error {
+	m := exportMonth{
+		Month:            yearMonth,
+		Days:             make([]exportDay, 0, len(reports)),
+		TotalWorkingTime: durationToString(ov.total.WorkingTime),
+		TotalOvertime:    durationToString(ov.total.Total()),
+		TotalLateNight:   durationToString(ov.total.LateNight),
+		TotalHolidayWork: durationToString(ov.total.Holiday),
+		OvertimeOver60:   durationToString(ov.total.Over60),
+		WorkedDays:       ov.workedDays,
+		BusinessDays:     ov.businessDays,
+		ProjectTotals:    newExportProjects(ov.projects),
+	}
+	for _, r := range reports {
+		o := ov.byDate[r.Date]
+		d := exportDay{
+			Date:        r.Date,
+			Sessions:    make([]exportSession, 0, len(r.Roudos)),
+			BreakTime:   durationToString(calculateTotalBreakTime(r.Roudos)),
+			WorkingTime: durationToString(calculateTotalWorkTime(r.Roudos)),
+			Overtime:    durationToString(o.Total()),
+			LateNight:   durationToString(o.LateNight),
+			HolidayWork: durationToString(o.Holiday),
+			Holiday:     ov.holidays[r.Date],
+		}
+		if roudo.HasTags([]roudo.DailyReport{r}) {
+			d.Projects = newExportProjects(roudo.CalcProjectTotals([]roudo.DailyReport{r}))
+		}
+		for _, ro := range r.Roudos {
+			s := exportSession{StartAt: ro.StartAt, EndAt: ro.EndAt, Breaks: make([]exportBreak, 0, len(ro.Breaks))}
+			for _, b := range ro.Breaks {
+				s.Breaks = append(s.Breaks, exportBreak{StartAt: b.StartAt, EndAt: b.EndAt})
+			}
+			for _, tag := range ro.Tags {
+				s.Tags = append(s.Tags, exportTag{Project: tag.Project, Task: tag.Task, StartAt: tag.StartAt, EndAt: tag.EndAt})
+			}
+			for _, a := range ro.Activities {
+				s.Activities = append(s.Activities, exportActivity{Label: a.Label, StartAt: a.StartAt, EndAt: a.EndAt})
+			}
+			d.Sessions = append(d.Sessions, s)
+		}
+		m.Days = append(m.Days, d)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+func writeMarkdown(w io.Writer, reports roudoReportForView, ov *overtimeSummary) error {
+	var sb strings.Builder
+	writeMarkdownRow(&sb, exportHeader)
+	sep := make([]string, len(exportHeader))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	writeMarkdownRow(&sb, sep)
+	for _, row := range exportRows(reports, ov) {
+		writeMarkdownRow(&sb, row)
+	}
+	writeMarkdownRow(&sb, ov.footer())
+	writeMarkdownRow(&sb, []string{"", "", "", "", "", "", "うち60時間超", durationToString(ov.total.Over60), "", "", "", ""})
+	sb.WriteString("\n" + ov.workingDaysText() + "\n")
+
+	if len(ov.projects) != 0 {
+		sb.WriteString("\n")
+		writeMarkdownRow(&sb, []string{"プロジェクト", "労働時間"})
+		writeMarkdownRow(&sb, []string{"---", "---"})
+		for _, row := range ov.projectRows() {
+			writeMarkdownRow(&sb, row)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeMarkdownRow(sb *strings.Builder, cells []string) {
+	sb.WriteString("|")
+	for _, c := range cells {
+		sb.WriteString(" ")
+		sb.WriteString(strings.ReplaceAll(c, "|", `\|`))
+		sb.WriteString(" |")
+	}
+	sb.WriteString("\n")
+}
+
+var htmlTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<title>{{.Month}}の勤怠</title>
+</head>
+<body>
+<h1>{{.Month}}の勤怠</h1>
+<table border="1">
+<thead>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+<tfoot>
+<tr>{{range .Footer}}<td>{{.}}</td>{{end}}</tr>
+<tr><td colspan="7">うち60時間超</td><td>{{.Over60}}</td><td></td><td></td><td></td><td></td></tr>
+</tfoot>
+</table>
+<p>{{.WorkingDays}}</p>
+{{if .Projects}}<table border="1">
+<thead>
+<tr><th>プロジェクト</th><th>労働時間</th></tr>
+</thead>
+<tbody>
+{{range .Projects}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+{{end}}</body>
+</html>
+`))
+
+func writeHTML(w io.Writer, yearMonth string, reports roudoReportForView, ov *overtimeSummary) error {
+	return htmlTemplate.Execute(w, struct {
+		Month       string
+		Header      []string
+		Rows        [][]string
+		Footer      []string
+		Over60      string
+		WorkingDays string
+		Projects    [][]string
+	}{
+		Month:       yearMonth,
+		Header:      exportHeader,
+		Rows:        exportRows(reports, ov),
+		Footer:      ov.footer(),
+		Over60:      durationToString(ov.total.Over60),
+		WorkingDays: ov.workingDaysText(),
+		Projects:    ov.projectRows(),
+	})
+}
@@ -0,0 +1,144 @@
+package view
+
+import (
+	"bytes"
+	"encoding/json"
+	"roudo/roudo"
+	"testing"
+	"time"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	const shiftDuration = 5 * time.Hour
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 3, day, hour, min, 0, 0, time.Local)
+	}
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	reports := map[roudo.Date][]roudo.Roudo{
+		"2024-03-01": {
+			{
+				StartAt: ptr(at(1, 9, 0)),
+				EndAt:   ptr(at(1, 12, 0)),
+				Tags:    []roudo.Tag{{Project: "roudo", Task: "review", StartAt: at(1, 9, 0), EndAt: ptr(at(1, 10, 30))}, {Project: "infra", StartAt: at(1, 10, 30)}},
+			},
+			{
+				StartAt: ptr(at(1, 13, 0)),
+				EndAt:   ptr(at(2, 1, 30)),
+				Breaks:  []roudo.Break{{StartAt: at(1, 15, 0), EndAt: ptr(at(1, 15, 15))}, {StartAt: at(1, 19, 0), EndAt: ptr(at(1, 20, 0))}},
+				Activities: []roudo.Activity{
+					{Label: "roudo", StartAt: at(1, 13, 0), EndAt: ptr(at(1, 18, 0))},
+					{Label: "browser", StartAt: at(1, 18, 0)},
+				},
+			},
+		},
+		"2024-03-04": {
+			{StartAt: ptr(at(4, 10, 0)), EndAt: ptr(at(4, 18, 45)), Breaks: []roudo.Break{{StartAt: at(4, 12, 0), EndAt: ptr(at(4, 13, 0))}}},
+		},
+	}
+	repo := roudo.NewMemoryRoudoReportRepository()
+	for date, rs := range reports {
+		if err := repo.SaveRoudoReport(date, rs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	month, err := MonthPeriod("2024-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		export ExportFormat
+		parse  roudo.ImportFormat
+		// CSV にはタグと作業内容を出力しない
+		withTags bool
+	}{
+		{export: ExportFormatJSON, parse: roudo.ImportFormatJSON, withTags: true},
+		{export: ExportFormatCSV, parse: roudo.ImportFormatCSV},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.export), func(t *testing.T) {
+			var buf bytes.Buffer
+			exporter, err := NewExporter(NewViewRepository(repo), roudo.NewHolidayCalendar(nil), tt.export, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := exporter.Do(month); err != nil {
+				t.Fatal(err)
+			}
+
+			imported, err := roudo.NewImporter(shiftDuration, time.Local).Parse(&buf, tt.parse)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(imported) != len(reports) {
+				t.Errorf("days = %d, want %d", len(imported), len(reports))
+			}
+			for date, want := range reports {
+				if !tt.withTags {
+					want = withoutTags(want)
+				}
+				if got, want := marshal(t, imported[date]), marshal(t, want); got != want {
+					t.Errorf("%s:\n got %s\nwant %s", date, got, want)
+				}
+			}
+		})
+	}
+}
+
+func withoutTags(rs []roudo.Roudo) []roudo.Roudo {
+	var stripped []roudo.Roudo
+	for _, r := range rs {
+		r.Tags = nil
+		r.Activities = nil
+		stripped = append(stripped, r)
+	}
+	return stripped
+}
+
+func marshal(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestExportCountsWeekSpanningMonths(t *testing.T) {
+	repo := roudo.NewMemoryRoudoReportRepository()
+	// 2024-05-01 (水) の週は 04-28 (日) から始まる。前月の月・火と5月の水~土に8時間ずつ働くと土曜日に40時間を超える
+	for _, day := range []time.Time{
+		time.Date(2024, 4, 29, 9, 0, 0, 0, time.Local),
+		time.Date(2024, 4, 30, 9, 0, 0, 0, time.Local),
+		time.Date(2024, 5, 1, 9, 0, 0, 0, time.Local),
+		time.Date(2024, 5, 2, 9, 0, 0, 0, time.Local),
+		time.Date(2024, 5, 3, 9, 0, 0, 0, time.Local),
+		time.Date(2024, 5, 4, 9, 0, 0, 0, time.Local),
+	} {
+		end := day.Add(8 * time.Hour)
+		if err := repo.SaveRoudoReport(roudo.Date(day.Format("2006-01-02")), []roudo.Roudo{{StartAt: &day, EndAt: &end}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	month, err := MonthPeriod("2024-05")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	exporter, err := NewExporter(NewViewRepository(repo), roudo.NewHolidayCalendar(nil), ExportFormatJSON, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := exporter.Do(month); err != nil {
+		t.Fatal(err)
+	}
+	var got exportMonth
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.TotalWorkingTime != "32:00" || got.TotalOvertime != "08:00" {
+		t.Errorf("working = %s, overtime = %s, want 32:00, 08:00", got.TotalWorkingTime, got.TotalOvertime)
+	}
+}
@@ -0,0 +1,112 @@
+package view
+
+import (
+	"fmt"
+	"roudo/roudo"
+	"time"
+)
+
+type periodKind string
+
+const (
+	periodMonth = periodKind("month")
+	periodWeek  = periodKind("week")
+	periodRange = periodKind("range")
+)
+
+// Period は表示する期間。From と To の日付を両端として含む
+type Period struct {
+	kind periodKind
+	From time.Time
+	To   time.Time
+}
+
+// MonthPeriod は 2006-01 形式の月の期間を返す
+func MonthPeriod(yearMonth string) (Period, error) {
+	from, to, err := getMonthStartEnd(yearMonth)
+	if err != nil {
+		return Period{}, err
+	}
+	return Period{kind: periodMonth, From: from, To: to}, nil
+}
+
+// WeekPeriod は t を含む1週間を返す。週40時間の時間外労働と同じ週にするため roudo.WeekStart で区切る
+func WeekPeriod(t time.Time) Period {
+	from := roudo.WeekStart(truncateDate(t))
+	return Period{kind: periodWeek, From: from, To: from.AddDate(0, 0, 6)}
+}
+
+// RangePeriod は 2006-01-02 形式の from から to までの期間を返す
+func RangePeriod(from, to string) (Period, error) {
+	f, err := roudo.Date(from).Time()
+	if err != nil {
+		return Period{}, fmt.Errorf("開始日の指定が不正です ex: 2024-03-01")
+	}
+	t, err := roudo.Date(to).Time()
+	if err != nil {
+		return Period{}, fmt.Errorf("終了日の指定が不正です ex: 2024-03-31")
+	}
+	if t.Before(f) {
+		return Period{}, fmt.Errorf("終了日が開始日より前です")
+	}
+	return Period{kind: periodRange, From: f, To: t}, nil
+}
+
+// IsMonth は月単位の期間かを返す。月60時間超の時間外労働は月単位でのみ意味を持つ
+func (p Period) IsMonth() bool {
+	return p.kind == periodMonth
+}
+
+func (p Period) days() int {
+	return int(p.To.Sub(p.From).Hours()/24+0.5) + 1
+}
+
+// Prev は同じ長さの1つ前の期間を返す
+func (p Period) Prev() Period {
+	switch p.kind {
+	case periodMonth:
+		from := p.From.AddDate(0, -1, 0)
+		return Period{kind: periodMonth, From: from, To: from.AddDate(0, 1, -1)}
+	default:
+		n := p.days()
+		return Period{kind: p.kind, From: p.From.AddDate(0, 0, -n), To: p.To.AddDate(0, 0, -n)}
+	}
+}
+
+// Next は同じ長さの1つ後の期間を返す
+func (p Period) Next() Period {
+	switch p.kind {
+	case periodMonth:
+		from := p.From.AddDate(0, 1, 0)
+		return Period{kind: periodMonth, From: from, To: from.AddDate(0, 1, -1)}
+	default:
+		n := p.days()
+		return Period{kind: p.kind, From: p.From.AddDate(0, 0, n), To: p.To.AddDate(0, 0, n)}
+	}
+}
+
+// Around は now を含む同じ種類の期間を返す。任意の期間の場合は now で終わる同じ長さの期間にする
+func (p Period) Around(now time.Time) Period {
+	switch p.kind {
+	case periodMonth:
+		p, _ := MonthPeriod(now.Format("2006-01"))
+		return p
+	case periodWeek:
+		return WeekPeriod(now)
+	default:
+		to := truncateDate(now)
+		return Period{kind: periodRange, From: to.AddDate(0, 0, -(p.days() - 1)), To: to}
+	}
+}
+
+// Label は画面や出力の見出しに使う期間の表記を返す
+func (p Period) Label() string {
+	if p.kind == periodMonth {
+		return p.From.Format("2006-01")
+	}
+	return p.From.Format("2006-01-02") + "~" + p.To.Format("2006-01-02")
+}
+
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
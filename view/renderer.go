@@ -1,5 +1,5 @@
 package view
 
 type Viewer interface {
-	Do(yearMonth string) error
+	Do(p Period) error
 }
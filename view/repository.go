@@ -7,47 +7,47 @@ import (
 )
 
 type ViewRepository interface {
-	ListReports(yearMonth string) (roudoReportForView, error)
+	ListReports(p Period) (roudoReportForView, error)
 }
 
 type viewRepository struct {
-	roudoRepo roudo.RoudoReportRepository
+	roudoRepo roudo.RoudoReportLister
 }
 
-func NewViewRepository(roudoRepo roudo.RoudoReportRepository) ViewRepository {
+func NewViewRepository(roudoRepo roudo.RoudoReportLister) ViewRepository {
 	return &viewRepository{roudoRepo}
 }
 
-func (r *viewRepository) ListReports(yearMonth string) (roudoReportForView, error) {
-	monthStart, monthEnd, err := getMonthStartEnd(yearMonth)
+// ListReports は期間内の全ての日を、記録のない日も含めて日付順に返す
+func (r *viewRepository) ListReports(p Period) (roudoReportForView, error) {
+	from := roudo.Date(p.From.Format("2006-01-02"))
+	to := roudo.Date(p.To.Format("2006-01-02"))
+	rsByDate, err := r.roudoRepo.ListRoudoReports(from, to)
 	if err != nil {
 		return nil, err
 	}
 
-	rsByDate := make(map[roudo.Date][]roudo.Roudo)
-	for d := monthStart; !d.After(monthEnd); d = d.AddDate(0, 0, 1) {
-		date := roudo.Date(d.Format("2006-01-02"))
-		rs, err := r.roudoRepo.GetRoudoReport(date)
-		if err != nil {
-			return nil, err
-		}
-		rsByDate[date] = rs
-	}
-
 	var reports roudoReportForView
-	for d := monthStart; !d.After(monthEnd); d = d.AddDate(0, 0, 1) {
+	for d := p.From; !d.After(p.To); d = d.AddDate(0, 0, 1) {
 		date := roudo.Date(d.Format("2006-01-02"))
-		reports = append(reports, struct {
-			Date   roudo.Date
-			Roudos []roudo.Roudo
-		}{Date: date, Roudos: rsByDate[date]})
+		reports = append(reports, roudo.DailyReport{Date: date, Roudos: rsByDate[date]})
 	}
 
 	return reports, nil
 }
 
+// listPrecedingWeek は p の最初の週のうち、p より前の日の記録を返す。
+// 月の初めの週の労働時間を、前月の分も含めて週40時間と比べるために使う
+func listPrecedingWeek(repo ViewRepository, p Period) (roudoReportForView, error) {
+	weekStart := roudo.WeekStart(p.From)
+	if weekStart.Equal(p.From) {
+		return nil, nil
+	}
+	return repo.ListReports(Period{kind: periodRange, From: weekStart, To: p.From.AddDate(0, 0, -1)})
+}
+
 func getMonthStartEnd(yearMonth string) (time.Time, time.Time, error) {
-	monthStart, err := time.Parse("2006-01", yearMonth)
+	monthStart, err := time.ParseInLocation("2006-01", yearMonth, time.Local)
 	if err != nil {
 		return time.Time{}, time.Time{}, fmt.Errorf("月の指定が不正です ex: 2024-03")
 	}
@@ -55,10 +55,7 @@ func getMonthStartEnd(yearMonth string) (time.Time, time.Time, error) {
 	return monthStart, monthEnd, nil
 }
 
-type roudoReportForView []struct {
-	Date   roudo.Date
-	Roudos []roudo.Roudo
-}
+type roudoReportForView []roudo.DailyReport
 
 type flattenRoudoReportForView struct {
 	Date       roudo.Date
@@ -68,6 +65,34 @@ type flattenRoudoReportForView struct {
 	BreakIndex int
 }
 
+// countWorkingDays は労働記録のある日数と所定労働日数を返す
+func countWorkingDays(reports roudoReportForView, calendar *roudo.HolidayCalendar) (int, int, error) {
+	worked, business := 0, 0
+	for _, r := range reports {
+		t, err := r.Date.Time()
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(r.Roudos) != 0 {
+			worked++
+		}
+		if calendar.IsWorkingDay(t) {
+			business++
+		}
+	}
+	return worked, business, nil
+}
+
+// holidayName は date が休日であればその名前を返す
+func holidayName(date roudo.Date, calendar *roudo.HolidayCalendar) string {
+	t, err := date.Time()
+	if err != nil {
+		return ""
+	}
+	name, _ := calendar.HolidayName(t)
+	return name
+}
+
 func (r roudoReportForView) FindByDate(date roudo.Date) []roudo.Roudo {
 	for _, report := range r {
 		if report.Date == date {
@@ -0,0 +1,207 @@
+package view
+
+import (
+	"cmp"
+	"roudo/roudo"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestViewRepositoryListReports(t *testing.T) {
+	repo := roudo.NewMemoryRoudoReportRepository()
+	session := func(date roudo.Date, hour int) []roudo.Roudo {
+		d, err := date.Time()
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := d.Add(time.Duration(hour) * time.Hour)
+		end := start.Add(8 * time.Hour)
+		return []roudo.Roudo{{StartAt: &start, EndAt: &end}}
+	}
+	for date, hour := range map[roudo.Date]int{
+		"2024-01-31": 9,
+		"2024-02-01": 10,
+		"2024-02-29": 11,
+		"2024-03-01": 12,
+		"2024-03-04": 13,
+	} {
+		if err := repo.SaveRoudoReport(date, session(date, hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustPeriod := func(p Period, err error) Period {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name      string
+		period    Period
+		wantFirst roudo.Date
+		wantLast  roudo.Date
+		wantDays  int
+		// 労働記録のある日と、その開始時刻
+		wantWorked map[roudo.Date]int
+	}{
+		{
+			name:       "閏年の2月は29日分を返す",
+			period:     mustPeriod(MonthPeriod("2024-02")),
+			wantFirst:  "2024-02-01",
+			wantLast:   "2024-02-29",
+			wantDays:   29,
+			wantWorked: map[roudo.Date]int{"2024-02-01": 10, "2024-02-29": 11},
+		},
+		{
+			name:       "記録のない月も全ての日を返す",
+			period:     mustPeriod(MonthPeriod("2023-11")),
+			wantFirst:  "2023-11-01",
+			wantLast:   "2023-11-30",
+			wantDays:   30,
+			wantWorked: map[roudo.Date]int{},
+		},
+		{
+			name:       "月を跨ぐ期間",
+			period:     mustPeriod(RangePeriod("2024-01-31", "2024-03-01")),
+			wantFirst:  "2024-01-31",
+			wantLast:   "2024-03-01",
+			wantDays:   31,
+			wantWorked: map[roudo.Date]int{"2024-01-31": 9, "2024-02-01": 10, "2024-02-29": 11, "2024-03-01": 12},
+		},
+		{
+			name:       "1日だけの期間",
+			period:     mustPeriod(RangePeriod("2024-03-04", "2024-03-04")),
+			wantFirst:  "2024-03-04",
+			wantLast:   "2024-03-04",
+			wantDays:   1,
+			wantWorked: map[roudo.Date]int{"2024-03-04": 13},
+		},
+		{
+			name:       "日曜始まりの週",
+			period:     WeekPeriod(time.Date(2024, 3, 2, 15, 0, 0, 0, time.Local)),
+			wantFirst:  "2024-02-25",
+			wantLast:   "2024-03-02",
+			wantDays:   7,
+			wantWorked: map[roudo.Date]int{"2024-02-29": 11, "2024-03-01": 12},
+		},
+		{
+			name:       "次の週",
+			period:     WeekPeriod(time.Date(2024, 3, 2, 15, 0, 0, 0, time.Local)).Next(),
+			wantFirst:  "2024-03-03",
+			wantLast:   "2024-03-09",
+			wantDays:   7,
+			wantWorked: map[roudo.Date]int{"2024-03-04": 13},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reports, err := NewViewRepository(repo).ListReports(tt.period)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(reports) != tt.wantDays {
+				t.Fatalf("days = %d, want %d", len(reports), tt.wantDays)
+			}
+			if reports[0].Date != tt.wantFirst || reports[len(reports)-1].Date != tt.wantLast {
+				t.Errorf("period = %s~%s, want %s~%s", reports[0].Date, reports[len(reports)-1].Date, tt.wantFirst, tt.wantLast)
+			}
+			if !slices.IsSortedFunc(reports, func(a, b roudo.DailyReport) int { return cmp.Compare(a.Date, b.Date) }) {
+				t.Error("日付順に並んでいません")
+			}
+
+			worked := 0
+			for _, r := range reports {
+				if len(r.Roudos) == 0 {
+					continue
+				}
+				worked++
+				hour, ok := tt.wantWorked[r.Date]
+				if !ok {
+					t.Errorf("%s に労働記録があります", r.Date)
+					continue
+				}
+				if got := r.Roudos[0].StartAt.Hour(); got != hour {
+					t.Errorf("%s の開始 = %d時, want %d時", r.Date, got, hour)
+				}
+			}
+			if worked != len(tt.wantWorked) {
+				t.Errorf("worked days = %d, want %d", worked, len(tt.wantWorked))
+			}
+		})
+	}
+}
+
+func TestViewRepositoryListReportsDoesNotShareRecords(t *testing.T) {
+	repo := roudo.NewMemoryRoudoReportRepository()
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	if err := repo.SaveRoudoReport("2024-03-01", []roudo.Roudo{{StartAt: &start}}); err != nil {
+		t.Fatal(err)
+	}
+	p, err := MonthPeriod("2024-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reports, err := NewViewRepository(repo).ListReports(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	*reports[0].Roudos[0].StartAt = start.Add(time.Hour)
+
+	rs, err := repo.GetRoudoReport("2024-03-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rs[0].StartAt.Equal(start) {
+		t.Errorf("表示用の記録を書き換えると保存されている記録も変わりました: %s", rs[0].StartAt)
+	}
+}
+
+func TestMonthPeriodInvalid(t *testing.T) {
+	for _, month := range []string{"", "2024-13", "2024/03", "202403"} {
+		if _, err := MonthPeriod(month); err == nil {
+			t.Errorf("MonthPeriod(%q) がエラーになりません", month)
+		}
+	}
+}
+
+func TestListPrecedingWeek(t *testing.T) {
+	repo := NewViewRepository(roudo.NewMemoryRoudoReportRepository())
+	month, err := MonthPeriod("2024-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sunday, err := MonthPeriod("2024-09")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		period    Period
+		wantFirst roudo.Date
+		wantDays  int
+	}{
+		// 2024-03-01 は金曜日
+		{name: "金曜日から始まる月", period: month, wantFirst: "2024-02-25", wantDays: 5},
+		{name: "日曜日から始まる月", period: sunday, wantDays: 0},
+		// 表示する週と週40時間を数える週は同じなので、前の週の記録はいらない
+		{name: "週", period: WeekPeriod(time.Date(2024, 3, 6, 12, 0, 0, 0, time.Local)), wantDays: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reports, err := listPrecedingWeek(repo, tt.period)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(reports) != tt.wantDays {
+				t.Fatalf("days = %d, want %d", len(reports), tt.wantDays)
+			}
+			if len(reports) != 0 && reports[0].Date != tt.wantFirst {
+				t.Errorf("first = %s, want %s", reports[0].Date, tt.wantFirst)
+			}
+		})
+	}
+}
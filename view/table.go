@@ -2,6 +2,7 @@ package view
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"roudo/roudo"
 	"time"
@@ -10,20 +11,22 @@ import (
 )
 
 type tableViewer struct {
-	repo ViewRepository
+	repo     ViewRepository
+	calendar *roudo.HolidayCalendar
+	w        io.Writer
 }
 
-func NewTableViewer(repo ViewRepository) Viewer {
-	return &tableViewer{repo: repo}
+func NewTableViewer(repo ViewRepository, calendar *roudo.HolidayCalendar) Viewer {
+	return &tableViewer{repo: repo, calendar: calendar, w: os.Stdout}
 }
 
-func (t *tableViewer) Do(yearMonth string) error {
-	reports, err := t.repo.ListReports(yearMonth)
+func (t *tableViewer) Do(p Period) error {
+	reports, err := t.repo.ListReports(p)
 	if err != nil {
 		return err
 	}
 
-	tb, err := buildTableWriter(reports)
+	tb, err := buildTableWriter(reports, t.calendar, t.w)
 	if err != nil {
 		return err
 	}
@@ -31,14 +34,17 @@ func (t *tableViewer) Do(yearMonth string) error {
 	return nil
 }
 
-func buildTableWriter(reports roudoReportForView) (table.Writer, error) {
+func buildTableWriter(reports roudoReportForView, calendar *roudo.HolidayCalendar, w io.Writer) (table.Writer, error) {
 	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
+	t.SetOutputMirror(w)
 	t.AppendHeader(table.Row{"日付", "労働開始", "労働終了", "休憩開始", "休憩終了", "休憩時間", "労働時間"})
 
 	totalWorkingTimeSum := time.Duration(0)
 	for _, rp := range reports {
-		date := rp.Date
+		date := string(rp.Date)
+		if name := holidayName(rp.Date, calendar); name != "" {
+			date += " " + name
+		}
 		rs := rp.Roudos
 		totalWorkingTime := calculateTotalWorkTime(rs)
 		totalWorkingTimeSum += totalWorkingTime
@@ -85,6 +91,11 @@ func buildTableWriter(reports roudoReportForView) (table.Writer, error) {
 		}
 	}
 	t.AppendFooter(table.Row{"", "", "", "", "総労働時間", durationToString(totalWorkingTimeSum)})
+	if roudo.HasTags(reports) {
+		for _, p := range roudo.CalcProjectTotals(reports) {
+			t.AppendFooter(table.Row{"", "", "", "", projectLabel(p.Project), durationToString(p.WorkingTime)})
+		}
+	}
 	t.SetColumnConfigs([]table.ColumnConfig{
 		{Number: 0, AutoMerge: true},
 		{Number: 1, AutoMerge: true},
@@ -127,12 +138,10 @@ func calculateTotalBreakTime(rs []roudo.Roudo) time.Duration {
 	return total
 }
 
+// durationToString は分単位に丸めてから HH:mm 形式にする。時と分を別々に丸めると 32:00 が 32:01 になる
 func durationToString(d time.Duration) string {
-	fl := 0.0
-	if d.Seconds() > 0 {
-		fl = 1
-	}
-	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes()+fl)%60)
+	m := int(d.Round(time.Minute) / time.Minute)
+	return fmt.Sprintf("%02d:%02d", m/60, m%60)
 }
 
 func ptrTimeToString(t *time.Time) string {
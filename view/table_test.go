@@ -0,0 +1,27 @@
+package view
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurationToString(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "00:00"},
+		{29 * time.Second, "00:00"},
+		{30 * time.Second, "00:01"},
+		{8 * time.Hour, "08:00"},
+		{32 * time.Hour, "32:00"},
+		{59*time.Minute + 30*time.Second, "01:00"},
+		{7*time.Hour + 59*time.Minute + 59*time.Second, "08:00"},
+		{100*time.Hour + 5*time.Minute, "100:05"},
+	}
+	for _, tt := range tests {
+		if got := durationToString(tt.d); got != tt.want {
+			t.Errorf("durationToString(%s) = %s, want %s", tt.d, got, tt.want)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package view
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
 	"roudo/roudo"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -12,37 +15,100 @@ import (
 	"github.com/rivo/tview"
 )
 
-func NewTUI(roudoReporter roudo.RoudoReporter, repo ViewRepository, logger *slog.Logger) Viewer {
+func NewTUI(editor roudo.RoudoEditor, repo ViewRepository, calendar *roudo.HolidayCalendar, validator *roudo.Validator, logger *slog.Logger) Viewer {
 	return &tui{
-		roudoReporter: roudoReporter,
-		repo:          repo,
-		logger:        logger,
+		editor:    editor,
+		repo:      repo,
+		calendar:  calendar,
+		validator: validator,
+		logger:    logger,
 	}
 }
 
 type tui struct {
-	roudoReporter roudo.RoudoReporter
-	repo          ViewRepository
+	editor    roudo.RoudoEditor
+	repo      ViewRepository
+	calendar  *roudo.HolidayCalendar
+	validator *roudo.Validator
 
 	logger *slog.Logger
 
-	app  *tview.Application
-	root *tview.Flex
+	app   *tview.Application
+	root  *tview.Flex
+	table *tview.Table
+
+	period  Period
+	history editHistory
+	// 画面下部に表示する直前の操作
+	status string
 }
 
-func (t *tui) Do(yearMonth string) error {
-	reports, err := t.repo.ListReports(yearMonth)
-	if err != nil {
+func (t *tui) Do(p Period) error {
+	t.period = p
+	t.app = tview.NewApplication()
+
+	// フォームの入力中は u や h などをそのまま入力できるように、表にフォーカスがある時だけ扱う
+	t.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if t.table == nil || t.app.GetFocus() != t.table {
+			return event
+		}
+		switch {
+		case event.Key() == tcell.KeyRune && event.Rune() == 'u':
+			t.undo()
+			return nil
+		case event.Key() == tcell.KeyCtrlR:
+			t.redo()
+			return nil
+		case event.Key() == tcell.KeyRune && event.Rune() == 'h':
+			t.move(t.period.Prev())
+			return nil
+		case event.Key() == tcell.KeyRune && event.Rune() == 'l':
+			t.move(t.period.Next())
+			return nil
+		case event.Key() == tcell.KeyRune && event.Rune() == 't':
+			t.move(t.period.Around(time.Now()))
+			return nil
+		}
+		return event
+	})
+
+	if err := t.render(); err != nil {
 		return err
 	}
+	return t.app.Run()
+}
 
-	if t.app != nil {
-		t.app.Stop()
+// move は表示する期間を p に切り替える
+func (t *tui) move(p Period) {
+	prev := t.period
+	t.period = p
+	if err := t.render(); err != nil {
+		t.logger.Error("failed to move period", slog.String("period", p.Label()), slog.String("err", err.Error()))
+		t.period = prev
+		t.status = p.Label() + " を表示できませんでした"
+		t.refresh()
 	}
+}
 
-	t.app = tview.NewApplication()
+// refresh は保存されている労働記録を読み直して表示し直す
+func (t *tui) refresh() {
+	if err := t.render(); err != nil {
+		t.logger.Error("failed to render roudo report", slog.String("err", err.Error()))
+	}
+}
 
-	table, err := newRoudoReportTable(reports)
+// render は t.period の労働記録を読み込んで画面を作り直す
+func (t *tui) render() error {
+	reports, err := t.repo.ListReports(t.period)
+	if err != nil {
+		return err
+	}
+	preceding, err := listPrecedingWeek(t.repo, t.period)
+	if err != nil {
+		return err
+	}
+
+	table, err := newRoudoReportTable(reports, preceding, t.calendar, t.period.IsMonth())
 	if err != nil {
 		return err
 	}
@@ -59,34 +125,36 @@ func (t *tui) Do(yearMonth string) error {
 		switch column {
 		case 1:
 			r := reports.Flatten()[row-rowOffset]
-			form, err := t.newWorkingForm(r, func(form *tview.Form, startAt, endAt *time.Time) func() {
-				return func() {
-					defer func() {
-						t.Do(yearMonth)
-					}()
-
-					currentReport := reports.FindByDate(r.Date)
-					if startAt == nil {
-						if len(currentReport) == 0 {
-							return
-						}
-						currentReport = append(currentReport[:r.RoudoIndex], currentReport[r.RoudoIndex+1:]...)
-						if err := t.roudoReporter.SaveRoudoReport(r.Date, currentReport); err != nil {
-							t.logger.Error("failed to save roudo report: ", err)
-						}
-					} else {
-						index := 0
-						if len(currentReport) <= r.RoudoIndex {
-							index = len(currentReport)
-							currentReport = append(currentReport, roudo.Roudo{})
-						}
-						currentReport[index].StartAt = startAt
-						currentReport[index].EndAt = endAt
-						if err := t.roudoReporter.SaveRoudoReport(r.Date, currentReport); err != nil {
-							t.logger.Error("failed to save roudo report: ", err)
+			form, err := t.newWorkingForm(r, func(startAt, endAt *time.Time, project *string) error {
+				before := reports.FindByDate(r.Date)
+				currentReport := roudo.CloneRoudos(before)
+				var label string
+				if startAt == nil {
+					if len(currentReport) == 0 {
+						t.refresh()
+						return nil
+					}
+					currentReport = append(currentReport[:r.RoudoIndex], currentReport[r.RoudoIndex+1:]...)
+					label = "労働を削除"
+				} else {
+					index := r.RoudoIndex
+					label = "労働を編集"
+					if len(currentReport) <= r.RoudoIndex {
+						index = len(currentReport)
+						currentReport = append(currentReport, roudo.Roudo{})
+						label = "労働を追加"
+					}
+					currentReport[index].StartAt = startAt
+					currentReport[index].EndAt = endAt
+					// プロジェクトを変更した場合は、労働全体を1つのプロジェクトとして付け直す
+					if project != nil {
+						currentReport[index].Tags = nil
+						if *project != "" {
+							currentReport[index].Tags = []roudo.Tag{{Project: *project, StartAt: *startAt}}
 						}
 					}
 				}
+				return t.saveIfValid(r.Date, before, currentReport, label)
 			}, func(form *tview.Form) func() {
 				return func() {
 					t.app.SetFocus(table)
@@ -100,34 +168,34 @@ func (t *tui) Do(yearMonth string) error {
 			t.app.SetFocus(form)
 		case 2:
 			r := reports.Flatten()[row-rowOffset]
-			form, err := newBreakingForm(r, func(form *tview.Form, startAt, endAt *time.Time) func() {
-				return func() {
-					defer func() {
-						t.Do(yearMonth)
-					}()
-
-					currentReport := reports.FindByDate(r.Date)
-					if startAt == nil {
-						if len(currentReport[r.RoudoIndex].Breaks) == 0 {
-							return
-						}
-						currentReport[r.RoudoIndex].Breaks = append(currentReport[r.RoudoIndex].Breaks[:r.BreakIndex], currentReport[r.RoudoIndex].Breaks[r.BreakIndex+1:]...)
-						if err := t.roudoReporter.SaveRoudoReport(r.Date, currentReport); err != nil {
-							t.logger.Error("failed to save roudo report: ", err)
-						}
-					} else {
-						index := 0
-						if len(currentReport[r.RoudoIndex].Breaks) <= r.BreakIndex {
-							index = len(currentReport[r.RoudoIndex].Breaks)
-							currentReport[r.RoudoIndex].Breaks = append(currentReport[r.RoudoIndex].Breaks, roudo.Break{})
-						}
-						currentReport[r.RoudoIndex].Breaks[index].StartAt = *startAt
-						currentReport[r.RoudoIndex].Breaks[index].EndAt = endAt
-						if err := t.roudoReporter.SaveRoudoReport(r.Date, currentReport); err != nil {
-							t.logger.Error("failed to save roudo report: ", err)
-						}
+			form, err := t.newBreakingForm(r, func(startAt, endAt *time.Time) error {
+				before := reports.FindByDate(r.Date)
+				currentReport := roudo.CloneRoudos(before)
+				if len(currentReport) <= r.RoudoIndex {
+					return fmt.Errorf("労働のない日には休憩を記録できません")
+				}
+				breaks := currentReport[r.RoudoIndex].Breaks
+				var label string
+				if startAt == nil {
+					if r.Break == nil {
+						t.refresh()
+						return nil
+					}
+					breaks = append(breaks[:r.BreakIndex], breaks[r.BreakIndex+1:]...)
+					label = "休憩を削除"
+				} else {
+					index := r.BreakIndex
+					label = "休憩を編集"
+					if r.Break == nil {
+						index = len(breaks)
+						breaks = append(breaks, roudo.Break{})
+						label = "休憩を追加"
 					}
+					breaks[index].StartAt = *startAt
+					breaks[index].EndAt = endAt
 				}
+				currentReport[r.RoudoIndex].Breaks = breaks
+				return t.saveIfValid(r.Date, before, currentReport, label)
 			}, func(form *tview.Form) func() {
 				return func() {
 					t.app.SetFocus(table)
@@ -142,14 +210,109 @@ func (t *tui) Do(yearMonth string) error {
 		}
 	})
 
+	worked, business, err := countWorkingDays(reports, t.calendar)
+	if err != nil {
+		return err
+	}
 	t.root = tview.NewFlex().
 		SetDirection(tview.FlexRow).
-		AddItem(tview.NewTextView().SetText(fmt.Sprintf("%sの勤怠", yearMonth)), 1, 1, false).
-		AddItem(flex, 0, 1, true)
-	return t.app.SetRoot(t.root, true).Run()
+		AddItem(tview.NewTextView().SetText(fmt.Sprintf("%sの勤怠  出勤日数: %d / 所定労働日数: %d", t.period.Label(), worked, business)), 1, 1, false).
+		AddItem(flex, 0, 1, true).
+		AddItem(tview.NewTextView().SetText(t.statusLine()), 1, 1, false)
+
+	t.table = table
+	t.app.SetRoot(t.root, true)
+	return nil
+}
+
+// saveIfValid は1日分の労働記録を検証して保存し、元に戻せるように履歴に積んで表示し直す。不整合があれば保存せずにエラーを返す
+func (t *tui) saveIfValid(date roudo.Date, before, after []roudo.Roudo, label string) error {
+	issues := t.validator.ValidateChange(date, before, after)
+	if err := issues.Err(); err != nil {
+		return err
+	}
+	// 表示した後に kansi などが書き換えた記録を上書きしないよう、表示した時の記録のままの場合だけ保存する
+	if err := t.editor.ReplaceRoudoReport(date, before, after, roudo.AuditSourceTUI); errors.Is(err, roudo.ErrReportChanged) {
+		t.status = dateLabel(date) + " の記録が他で更新されていたため保存しませんでした。表示し直しました"
+		t.refresh()
+		return nil
+	} else if err != nil {
+		t.logger.Error("failed to save roudo report", slog.String("err", err.Error()))
+		return err
+	}
+
+	label = dateLabel(date) + " の" + label
+	t.history.push(edit{label: label, date: date, before: before, after: roudo.CloneRoudos(after)})
+	t.status = label
+	if ws := issues.Warnings(); len(ws) != 0 {
+		t.status += "  警告: " + strings.Join(ws, " / ")
+	}
+	t.refresh()
+	return nil
+}
+
+// undo は直前の編集の前の労働記録に戻す
+func (t *tui) undo() {
+	e, ok := t.history.popUndo()
+	if !ok {
+		t.status = "元に戻す編集はありません"
+		t.refresh()
+		return
+	}
+	// 編集した後に書き換えられた記録は、丸ごと戻すと他の変更を消してしまうので戻さない
+	if err := t.editor.ReplaceRoudoReport(e.date, e.after, e.before, roudo.AuditSourceTUI); errors.Is(err, roudo.ErrReportChanged) {
+		t.status = "編集した後に記録が更新されているため元に戻せません: " + e.label
+	} else if err != nil {
+		t.logger.Error("failed to undo", slog.String("err", err.Error()))
+		t.history.pushUndo(e)
+		t.status = "元に戻せませんでした: " + e.label
+	} else {
+		t.history.pushRedo(e)
+		t.status = "元に戻しました: " + e.label
+	}
+	t.refresh()
+}
+
+// redo は元に戻した編集をもう一度適用する
+func (t *tui) redo() {
+	e, ok := t.history.popRedo()
+	if !ok {
+		t.status = "やり直す編集はありません"
+		t.refresh()
+		return
+	}
+	if err := t.editor.ReplaceRoudoReport(e.date, e.before, e.after, roudo.AuditSourceTUI); errors.Is(err, roudo.ErrReportChanged) {
+		t.status = "元に戻した後に記録が更新されているためやり直せません: " + e.label
+	} else if err != nil {
+		t.logger.Error("failed to redo", slog.String("err", err.Error()))
+		t.history.pushRedo(e)
+		t.status = "やり直せませんでした: " + e.label
+	} else {
+		t.history.pushUndo(e)
+		t.status = "やり直しました: " + e.label
+	}
+	t.refresh()
 }
 
-func newRoudoReportTable(reports roudoReportForView) (*tview.Table, error) {
+func (t *tui) statusLine() string {
+	help := "h/l: 前/次の期間  t: 今日  u: 元に戻す  Ctrl-R: やり直す"
+	if t.status == "" {
+		return help
+	}
+	return t.status + "  (" + help + ")"
+}
+
+func dateLabel(date roudo.Date) string {
+	d, err := date.Time()
+	if err != nil {
+		return string(date)
+	}
+	return d.Format("01/02")
+}
+
+// newRoudoReportTable は労働記録の表を作る。preceding は期間の前の同じ週の記録で、週40時間の計算にだけ使う。
+// 月60時間超の時間外労働は月単位で表示する時だけ showOver60 で出す
+func newRoudoReportTable(reports, preceding roudoReportForView, calendar *roudo.HolidayCalendar, showOver60 bool) (*tview.Table, error) {
 	table := tview.NewTable().SetBorders(true)
 
 	table.SetCell(0, 0, tview.NewTableCell("日付").SetAlign(tview.AlignCenter).SetSelectable(false))
@@ -157,11 +320,19 @@ func newRoudoReportTable(reports roudoReportForView) (*tview.Table, error) {
 	table.SetCell(0, 2, tview.NewTableCell("休憩").SetAlign(tview.AlignCenter).SetSelectable(false))
 	table.SetCell(0, 3, tview.NewTableCell("休憩時間").SetAlign(tview.AlignCenter).SetSelectable(false))
 	table.SetCell(0, 4, tview.NewTableCell("労働時間").SetAlign(tview.AlignCenter).SetSelectable(false))
+	table.SetCell(0, 5, tview.NewTableCell("時間外").SetAlign(tview.AlignCenter).SetSelectable(false))
+	table.SetCell(0, 6, tview.NewTableCell("深夜").SetAlign(tview.AlignCenter).SetSelectable(false))
+	table.SetCell(0, 7, tview.NewTableCell("休日").SetAlign(tview.AlignCenter).SetSelectable(false))
+
+	overtimes, totalOvertime, err := roudo.CalcOvertime(reports, preceding, calendar.IsLegalHoliday)
+	if err != nil {
+		return nil, err
+	}
 
 	offset := 1
 	totalWorkingTime := time.Duration(0)
 	for repoIdx, report := range reports {
-		date, err := dateToCell(report.Date)
+		date, err := dateToCell(report.Date, calendar)
 		if err != nil {
 			return nil, err
 		}
@@ -187,15 +358,34 @@ func newRoudoReportTable(reports roudoReportForView) (*tview.Table, error) {
 
 		table.SetCell(repoIdx+offset, 3, tview.NewTableCell(durationToString(breakingTime)).SetAlign(tview.AlignCenter).SetSelectable(false))
 		table.SetCell(repoIdx+offset, 4, tview.NewTableCell(durationToString(workingTime)).SetAlign(tview.AlignCenter).SetSelectable(false))
+		setOvertimeCells(table, repoIdx+offset, overtimes[repoIdx].Overtime)
 		totalWorkingTime += workingTime
 	}
 
 	table.SetCell(len(reports)+offset, 3, tview.NewTableCell("総労働時間").SetAlign(tview.AlignCenter).SetSelectable(false))
 	table.SetCell(len(reports)+offset, 4, tview.NewTableCell(durationToString(totalWorkingTime)).SetAlign(tview.AlignCenter).SetSelectable(false))
+	setOvertimeCells(table, len(reports)+offset, totalOvertime)
+	if showOver60 {
+		table.SetCell(len(reports)+offset+1, 4, tview.NewTableCell("うち60時間超").SetAlign(tview.AlignCenter).SetSelectable(false))
+		table.SetCell(len(reports)+offset+1, 5, tview.NewTableCell(durationToString(totalOvertime.Over60)).SetAlign(tview.AlignCenter).SetSelectable(false))
+	}
+	if roudo.HasTags(reports) {
+		for i, p := range roudo.CalcProjectTotals(reports) {
+			table.SetCell(len(reports)+offset+2+i, 3, tview.NewTableCell(projectLabel(p.Project)).SetAlign(tview.AlignCenter).SetSelectable(false))
+			table.SetCell(len(reports)+offset+2+i, 4, tview.NewTableCell(durationToString(p.WorkingTime)).SetAlign(tview.AlignCenter).SetSelectable(false))
+		}
+	}
 	return table, nil
 }
 
-func (t *tui) newWorkingForm(r flattenRoudoReportForView, handleSave func(form *tview.Form, startAt, endAt *time.Time) func(), handleCancel func(form *tview.Form) func()) (*tview.Form, error) {
+func setOvertimeCells(table *tview.Table, row int, o roudo.Overtime) {
+	table.SetCell(row, 5, tview.NewTableCell(durationToString(o.Total())).SetAlign(tview.AlignCenter).SetSelectable(false))
+	table.SetCell(row, 6, tview.NewTableCell(durationToString(o.LateNight)).SetAlign(tview.AlignCenter).SetSelectable(false))
+	table.SetCell(row, 7, tview.NewTableCell(durationToString(o.Holiday)).SetAlign(tview.AlignCenter).SetSelectable(false))
+}
+
+// newWorkingForm の handleSave には、プロジェクトを変更した場合のみ project を渡す。複数のプロジェクトが付いた労働のプロジェクトは変更できない。handleSave がエラーを返した場合はフォームに表示する
+func (t *tui) newWorkingForm(r flattenRoudoReportForView, handleSave func(startAt, endAt *time.Time, project *string) error, handleCancel func(form *tview.Form) func()) (*tview.Form, error) {
 	startAt := ""
 	if r.Roudo.StartAt != nil {
 		startAt = timeToString(r.Roudo.StartAt)
@@ -204,6 +394,11 @@ func (t *tui) newWorkingForm(r flattenRoudoReportForView, handleSave func(form *
 	if r.Roudo.EndAt != nil {
 		endAt = timeToString(r.Roudo.EndAt)
 	}
+	projects := sessionProjects(r.Roudo)
+	// 区間ごとに別のプロジェクトが付いた労働は、1つのプロジェクトに付け直すと区間が失われるので変更させない
+	editableProject := len(projects) <= 1
+	initialProject := strings.Join(projects, ", ")
+	project := initialProject
 	form := tview.NewForm().
 		AddInputField("出勤時刻(HH:mm)", startAt, 0, nil, func(text string) {
 			startAt = text
@@ -211,31 +406,43 @@ func (t *tui) newWorkingForm(r flattenRoudoReportForView, handleSave func(form *
 		AddInputField("退勤時刻(HH:mm)", endAt, 0, nil, func(text string) {
 			endAt = text
 		}).
+		AddInputField("プロジェクト", project, 0, nil, func(text string) {
+			project = text
+		}).
 		AddTextView("", "", 0, 0, false, false)
+	if !editableProject {
+		form.GetFormItem(2).(*tview.InputField).
+			SetLabel("プロジェクト(複数)").
+			SetDisabled(true)
+	}
+	showError := func(msg string) {
+		form.GetFormItem(3).(*tview.TextView).
+			SetLabel("エラー").
+			SetText(msg)
+	}
 	form.
 		AddButton("保存", func() {
-			var s, e *time.Time
-			if startAt != "" {
-				ps, err := time.Parse("15:04", startAt)
-				if err != nil {
-					form.GetFormItem(2).(*tview.TextView).
-						SetLabel("エラー").
-						SetText("出勤時刻の形式が不正です")
-					return
-				}
-				s = &ps
+			s, err := t.parseFormTime(r.Date, startAt)
+			if err != nil {
+				showError("出勤時刻: " + err.Error())
+				return
+			}
+			e, err := t.parseFormTime(r.Date, endAt)
+			if err != nil {
+				showError("退勤時刻: " + err.Error())
+				return
 			}
-			if endAt != "" {
-				pe, err := time.Parse("15:04", endAt)
-				if err != nil {
-					form.GetFormItem(2).(*tview.TextView).
-						SetLabel("エラー").
-						SetText("退勤時刻の形式が不正です")
+			var p *string
+			if editableProject && project != initialProject {
+				if s == nil && project != "" {
+					showError("出勤時刻のない労働にはプロジェクトを付けられません")
 					return
 				}
-				e = &pe
+				p = &project
+			}
+			if err := handleSave(s, e, p); err != nil {
+				showError(err.Error())
 			}
-			handleSave(form, s, e)()
 		}).
 		AddButton("キャンセル", func() {
 			handleCancel(form)()
@@ -244,7 +451,8 @@ func (t *tui) newWorkingForm(r flattenRoudoReportForView, handleSave func(form *
 	return form, nil
 }
 
-func newBreakingForm(r flattenRoudoReportForView, handleSave func(form *tview.Form, startAt, endAt *time.Time) func(), handleCancel func(form *tview.Form) func()) (*tview.Form, error) {
+// newBreakingForm は handleSave がエラーを返した場合はフォームに表示する
+func (t *tui) newBreakingForm(r flattenRoudoReportForView, handleSave func(startAt, endAt *time.Time) error, handleCancel func(form *tview.Form) func()) (*tview.Form, error) {
 	startAt := ""
 	if r.Break != nil {
 		startAt = timeToString(&r.Break.StartAt)
@@ -259,40 +467,60 @@ func newBreakingForm(r flattenRoudoReportForView, handleSave func(form *tview.Fo
 		}).
 		AddInputField("休憩終了時刻(HH:mm)", endAt, 0, nil, func(text string) {
 			endAt = text
-		})
+		}).
+		AddTextView("", "", 0, 0, false, false)
+	showError := func(msg string) {
+		form.GetFormItem(2).(*tview.TextView).
+			SetLabel("エラー").
+			SetText(msg)
+	}
 	form.AddButton("保存", func() {
-		var s, e *time.Time
-		if startAt != "" {
-			ps, err := time.Parse("15:04", startAt)
-			if err != nil {
-				form.GetFormItem(2).(*tview.TextView).
-					SetLabel("エラー").
-					SetText("休憩開始時刻の形式が不正です")
-				return
-			}
-			s = &ps
+		s, err := t.parseFormTime(r.Date, startAt)
+		if err != nil {
+			showError("休憩開始時刻: " + err.Error())
+			return
 		}
-		if endAt != "" {
-			pe, err := time.Parse("15:04", endAt)
-			if err != nil {
-				form.GetFormItem(2).(*tview.TextView).
-					SetLabel("エラー").
-					SetText("休憩終了時刻の形式が不正です")
-				return
-			}
-			e = &pe
+		e, err := t.parseFormTime(r.Date, endAt)
+		if err != nil {
+			showError("休憩終了時刻: " + err.Error())
+			return
+		}
+		if err := handleSave(s, e); err != nil {
+			showError(err.Error())
 		}
-		handleSave(form, s, e)()
 	}).
 		AddButton("キャンセル", handleCancel(form))
 	form.SetBorder(true).SetTitle("勤怠入力（休憩）").SetTitleAlign(tview.AlignLeft)
 	return form, nil
 }
 
+// parseFormTime は入力された HH:mm を行の日付の時刻にする。空であれば nil を返す
+func (t *tui) parseFormTime(date roudo.Date, s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	pt, err := t.validator.ParseTime(date, s)
+	if err != nil {
+		return nil, err
+	}
+	return &pt, nil
+}
+
+// sessionProjects は労働に付いているプロジェクトを重複なく付いた順に返す
+func sessionProjects(r roudo.Roudo) []string {
+	var projects []string
+	for _, tag := range r.Tags {
+		if !slices.Contains(projects, tag.Project) {
+			projects = append(projects, tag.Project)
+		}
+	}
+	return projects
+}
+
 var week = []string{"日", "月", "火", "水", "木", "金", "土"}
 
-func dateToCell(d roudo.Date) (*tview.TableCell, error) {
-	t, err := time.Parse("2006-01-02", string(d))
+func dateToCell(d roudo.Date, calendar *roudo.HolidayCalendar) (*tview.TableCell, error) {
+	t, err := d.Time()
 	if err != nil {
 		return nil, err
 	}
@@ -305,6 +533,10 @@ func dateToCell(d roudo.Date) (*tview.TableCell, error) {
 	}
 
 	s := fmt.Sprintf(" %s (%s) ", t.Format("01/02"), week[t.Weekday()])
+	if name, ok := calendar.HolidayName(t); ok {
+		color = tcell.ColorRed
+		s += name + " "
+	}
 	return tview.NewTableCell(s).SetTextColor(color).SetAlign(tview.AlignCenter), nil
 }
 
@@ -322,5 +554,5 @@ func timeToString(t *time.Time) string {
 	if t == nil {
 		return emptyTimeStr
 	}
-	return t.Format("15:04")
+	return t.Local().Format("15:04")
 }
@@ -0,0 +1,81 @@
+package view
+
+import (
+	"roudo/roudo"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// pressKey は p にキー入力を1つ送る
+func pressKey(p tview.Primitive, key tcell.Key, r rune) {
+	p.InputHandler()(tcell.NewEventKey(key, r, tcell.ModNone), func(tview.Primitive) {})
+}
+
+func TestWorkingFormProject(t *testing.T) {
+	at := func(h int) time.Time {
+		return time.Date(2024, 3, 1, h, 0, 0, 0, time.Local)
+	}
+	start, end := at(9), at(18)
+	tests := []struct {
+		name string
+		tags []roudo.Tag
+		want *string
+	}{
+		{
+			name: "プロジェクトが1つなら変更できる",
+			tags: []roudo.Tag{{Project: "a", StartAt: at(9)}},
+			want: ptr("ax"),
+		},
+		{
+			name: "プロジェクトがなければ付けられる",
+			want: ptr("x"),
+		},
+		{
+			name: "区間ごとにプロジェクトが違う労働は変更しない",
+			tags: []roudo.Tag{{Project: "a", StartAt: at(9)}, {Project: "b", StartAt: at(13)}},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tui := &tui{validator: roudo.NewValidator(0)}
+			r := flattenRoudoReportForView{
+				Date:  "2024-03-01",
+				Roudo: roudo.Roudo{StartAt: &start, EndAt: &end, Tags: tt.tags},
+			}
+			saved := false
+			var got *string
+			form, err := tui.newWorkingForm(r, func(_, _ *time.Time, project *string) error {
+				saved = true
+				got = project
+				return nil
+			}, func(*tview.Form) func() { return func() {} })
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pressKey(form.GetFormItem(2), tcell.KeyRune, 'x')
+			pressKey(form.GetButton(0), tcell.KeyEnter, 0)
+			if !saved {
+				t.Fatal("保存されませんでした")
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("project = %v, want %v", deref(got), deref(tt.want))
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
@@ -0,0 +1,53 @@
+package view
+
+import (
+	"roudo/roudo"
+)
+
+// edit は TUI での1回の保存。1日分の労働記録を丸ごと入れ替えたものとして扱う
+type edit struct {
+	label  string
+	date   roudo.Date
+	before []roudo.Roudo
+	after  []roudo.Roudo
+}
+
+// editHistory は元に戻す・やり直すための編集の履歴
+type editHistory struct {
+	undo []edit
+	redo []edit
+}
+
+// push は新しい編集を積む。やり直しの履歴は捨てる
+func (h *editHistory) push(e edit) {
+	h.undo = append(h.undo, e)
+	h.redo = nil
+}
+
+// pushUndo はやり直した編集や、元に戻せなかった編集を元に戻せるように積み直す。やり直しの履歴は残す
+func (h *editHistory) pushUndo(e edit) {
+	h.undo = append(h.undo, e)
+}
+
+// pushRedo は元に戻した編集や、やり直せなかった編集をやり直せるように積み直す
+func (h *editHistory) pushRedo(e edit) {
+	h.redo = append(h.redo, e)
+}
+
+func (h *editHistory) popUndo() (edit, bool) {
+	if len(h.undo) == 0 {
+		return edit{}, false
+	}
+	e := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	return e, true
+}
+
+func (h *editHistory) popRedo() (edit, bool) {
+	if len(h.redo) == 0 {
+		return edit{}, false
+	}
+	e := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	return e, true
+}
@@ -0,0 +1,147 @@
+package view
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"roudo/roudo"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rivo/tview"
+)
+
+func TestEditHistory(t *testing.T) {
+	var h editHistory
+	a, b, c := edit{label: "a"}, edit{label: "b"}, edit{label: "c"}
+	labels := func(es []edit) []string {
+		var ls []string
+		for _, e := range es {
+			ls = append(ls, e.label)
+		}
+		return ls
+	}
+	expect := func(step string, undo, redo []string) {
+		t.Helper()
+		if got := labels(h.undo); !slices.Equal(got, undo) {
+			t.Errorf("%s: undo = %v, want %v", step, got, undo)
+		}
+		if got := labels(h.redo); !slices.Equal(got, redo) {
+			t.Errorf("%s: redo = %v, want %v", step, got, redo)
+		}
+	}
+
+	h.push(a)
+	h.push(b)
+	expect("push", []string{"a", "b"}, nil)
+
+	e, ok := h.popUndo()
+	if !ok || e.label != "b" {
+		t.Fatalf("popUndo = %v, %v, want b", e.label, ok)
+	}
+	h.pushRedo(e)
+	expect("undo", []string{"a"}, []string{"b"})
+
+	e, ok = h.popRedo()
+	if !ok || e.label != "b" {
+		t.Fatalf("popRedo = %v, %v, want b", e.label, ok)
+	}
+	h.pushUndo(e)
+	expect("redo", []string{"a", "b"}, nil)
+
+	e, _ = h.popUndo()
+	h.pushRedo(e)
+	// 新しく編集するとやり直しの履歴は捨てる
+	h.push(c)
+	expect("new edit", []string{"a", "c"}, nil)
+	if _, ok := h.popRedo(); ok {
+		t.Error("新しく編集した後にやり直せます")
+	}
+}
+
+// failingEditor は fail の間 ReplaceRoudoReport に失敗する
+type failingEditor struct {
+	roudo.RoudoEditor
+	fail bool
+}
+
+func (e *failingEditor) ReplaceRoudoReport(date roudo.Date, expected, rs []roudo.Roudo, source roudo.AuditSource) error {
+	if e.fail {
+		return errors.New("disk full")
+	}
+	return e.RoudoEditor.ReplaceRoudoReport(date, expected, rs, source)
+}
+
+func TestTUIUndoRedo(t *testing.T) {
+	date := roudo.Date("2024-03-01")
+	at := func(hour int) *time.Time {
+		t := time.Date(2024, 3, 1, hour, 0, 0, 0, time.Local)
+		return &t
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := roudo.NewMemoryRoudoReportRepository()
+	reporter := roudo.NewRoudoReporter(repo, roudo.NewMemoryAuditLog(), logger, &roudo.NopNotificator{}, roudo.NewProcessMutex(), roudo.NewSystemClock(), roudo.DefaultConfig())
+	editor := &failingEditor{RoudoEditor: reporter}
+	p, err := MonthPeriod("2024-03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tui := &tui{
+		editor:    editor,
+		repo:      NewViewRepository(editor),
+		calendar:  roudo.NewHolidayCalendar(nil),
+		validator: roudo.NewValidator(0),
+		logger:    logger,
+		app:       tview.NewApplication(),
+		period:    p,
+	}
+
+	expect := func(step string, want []roudo.Roudo, undo, redo int) {
+		t.Helper()
+		got, err := repo.GetRoudoReport(date)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) || len(got) != 0 && !got[0].EndAt.Equal(*want[0].EndAt) {
+			t.Errorf("%s: roudos = %+v, want %+v", step, got, want)
+		}
+		if len(tui.history.undo) != undo || len(tui.history.redo) != redo {
+			t.Errorf("%s: undo = %d, redo = %d, want %d, %d", step, len(tui.history.undo), len(tui.history.redo), undo, redo)
+		}
+	}
+
+	first := []roudo.Roudo{{StartAt: at(9), EndAt: at(17)}}
+	second := []roudo.Roudo{{StartAt: at(9), EndAt: at(18)}}
+	if err := tui.saveIfValid(date, nil, first, "労働を追加"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tui.saveIfValid(date, first, second, "労働を編集"); err != nil {
+		t.Fatal(err)
+	}
+	expect("edit", second, 2, 0)
+
+	tui.undo()
+	expect("undo", first, 1, 1)
+	tui.redo()
+	expect("redo", second, 2, 0)
+	tui.undo()
+	expect("undo again", first, 1, 1)
+
+	// 保存に失敗しても、どちらの履歴も変わらない
+	editor.fail = true
+	tui.undo()
+	expect("failed undo", first, 1, 1)
+	tui.redo()
+	expect("failed redo", first, 1, 1)
+	editor.fail = false
+
+	// 新しく編集するとやり直しの履歴は捨てる
+	third := []roudo.Roudo{{StartAt: at(9), EndAt: at(19)}}
+	if err := tui.saveIfValid(date, first, third, "労働を編集"); err != nil {
+		t.Fatal(err)
+	}
+	expect("new edit", third, 2, 0)
+	tui.redo()
+	expect("redo after new edit", third, 2, 0)
+}